@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"fortio.org/tbonsai/ptree"
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// Colors of the -ascii pot.
+var (
+	asciiPotColor  = tcolor.RGBColor{R: 0xC0, G: 0xC0, B: 0xC0}
+	asciiSoilColor = tcolor.RGBColor{R: 0x80, G: 0x80, B: 0x80}
+	asciiRimColor  = tcolor.RGBColor{R: 0x00, G: 0xA0, B: 0x00}
+)
+
+// isTerminal returns true if f is a terminal (character device), e.g not redirected to a file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// RenderASCII draws the current tree (and pot) as characters, in place on the screen or,
+// with -exit, as plain lines of text that can be redirected to a file.
+func (st *State) RenderASCII() {
+	grid := ptree.DrawASCII(&st.Canvas)
+	if st.pot {
+		for len(grid) < st.ap.H {
+			grid = append(grid, make([]ptree.ASCIICell, st.Canvas.Width))
+		}
+		asciiPot(grid, st.ap.W, st.ap.H)
+	}
+	if st.exit {
+		for _, line := range grid {
+			_, _ = st.ap.Out.WriteString(st.asciiLine(line))
+			_, _ = st.ap.Out.WriteString("\n")
+		}
+		_ = st.ap.Out.Flush()
+		return
+	}
+	st.ap.StartSyncMode()
+	st.ap.ClearScreen()
+	for y, line := range grid {
+		st.ap.WriteAtStr(0, y, st.asciiLine(line))
+	}
+	st.ap.EndSyncMode()
+}
+
+// asciiLine returns the text of a row of cells, without trailing spaces.
+func (st *State) asciiLine(cells []ptree.ASCIICell) string {
+	end := len(cells)
+	for end > 0 && cells[end-1].Char == 0 {
+		end--
+	}
+	var sb strings.Builder
+	var last tcolor.RGBColor
+	colored := false
+	for _, cell := range cells[:end] {
+		if cell.Char == 0 {
+			sb.WriteByte(' ')
+			continue
+		}
+		if st.colors && (!colored || cell.Color != last) {
+			last, colored = cell.Color, true
+			sb.WriteString(st.ap.ColorOutput.Foreground(last.Color()))
+		}
+		sb.WriteByte(cell.Char)
+	}
+	if colored {
+		sb.WriteString(tcolor.Reset)
+	}
+	return sb.String()
+}
+
+// asciiPot draws the same pot as [State.Pot] with ASCII characters in the empty cells of the
+// bottom 4 rows of the grid.
+func asciiPot(grid [][]ptree.ASCIICell, w, h int) {
+	set := func(x, y int, s string, rgb tcolor.RGBColor) {
+		for i := range len(s) {
+			if y >= 0 && y < len(grid) && x+i >= 0 && x+i < len(grid[y]) && grid[y][x+i].Char == 0 {
+				grid[y][x+i] = ptree.ASCIICell{Char: s[i], Color: rgb}
+			}
+		}
+	}
+	cx, radius := potSize(w)
+	set(cx-radius-1, h-4, strings.Repeat("_", 2*radius+3), asciiRimColor)
+	set(cx-radius-1, h-3, "\\", asciiPotColor)
+	set(cx+radius+1, h-3, "/", asciiPotColor)
+	set(cx-radius, h-2, "\\", asciiPotColor)
+	set(cx-radius+1, h-2, strings.Repeat("_", 2*radius-1), asciiSoilColor)
+	set(cx+radius, h-2, "/", asciiPotColor)
+	set(cx-radius+5, h-1, "o", asciiSoilColor)
+	set(cx+radius-5, h-1, "o", asciiSoilColor)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image"
+
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// Pixels per Braille cell and minimum alpha for a dot to be set.
+const (
+	BrailleCellW   = 2
+	BrailleCellH   = 4
+	brailleMinAlph = 0x60
+)
+
+// brailleDots are the Unicode Braille pattern bits for each pixel of the 2x4 cell, by row.
+var brailleDots = [BrailleCellH][BrailleCellW]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// ImageToBraille converts an image into a grid of Braille glyphs (U+2800 block), one per
+// 2x4 pixels, with a dot for each covered pixel and the cell color taken from the most
+// common color among them.
+func ImageToBraille(img *image.RGBA) [][]Glyph {
+	b := img.Bounds()
+	cols, rows := b.Dx()/BrailleCellW, b.Dy()/BrailleCellH
+	grid := make([][]Glyph, rows)
+	var colors [BrailleCellW * BrailleCellH]tcolor.RGBColor
+	for row := range rows {
+		grid[row] = make([]Glyph, cols)
+		for col := range cols {
+			pattern := rune(0)
+			n := 0
+			for y := range BrailleCellH {
+				for x := range BrailleCellW {
+					off := img.PixOffset(b.Min.X+col*BrailleCellW+x, b.Min.Y+row*BrailleCellH+y)
+					pix := img.Pix[off : off+4 : off+4]
+					if pix[3] < brailleMinAlph {
+						continue
+					}
+					pattern |= brailleDots[y][x]
+					// Un-premultiply alpha.
+					a := uint32(pix[3])
+					colors[n] = tcolor.RGBColor{
+						R: uint8(uint32(pix[0]) * 255 / a),
+						G: uint8(uint32(pix[1]) * 255 / a),
+						B: uint8(uint32(pix[2]) * 255 / a),
+					}
+					n++
+				}
+			}
+			if pattern == 0 {
+				grid[row][col] = Glyph{Str: " "}
+				continue
+			}
+			grid[row][col] = Glyph{Str: string(0x2800 + pattern), Color: dominantColor(colors[:n])}
+		}
+	}
+	return grid
+}
+
+// dominantColor returns the most frequent color (the first one in case of ties).
+func dominantColor(colors []tcolor.RGBColor) tcolor.RGBColor {
+	best, bestCount := colors[0], 0
+	for i, c := range colors {
+		count := 0
+		for _, o := range colors[i:] {
+			if o == c {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = c, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fortio.org/log"
+	"fortio.org/tbonsai/ptree"
+)
+
+// Frame file names in -frames directories (ffmpeg image2 pattern).
+const framePattern = "frame_%04d.png"
+
+// defaultFramesDuration is the length of the -frames sequence when there is no -grow duration
+// (nor -season cycle).
+const defaultFramesDuration = 5 * time.Second
+
+// FramesManifest describes a -frames PNG sequence so it can be assembled into a video offline.
+type FramesManifest struct {
+	Pattern  string            `json:"pattern"`
+	Frames   int               `json:"frames"`
+	FPS      float64           `json:"fps"`
+	Duration string            `json:"duration"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Flags    map[string]string `json:"flags"`  // command line flags that were set
+	FFmpeg   string            `json:"ffmpeg"` // suggested command to make a video
+}
+
+// FramesMode renders the time based animations (growth, wind, seasons, falling leaves, snow) of one
+// generated tree headless, at a fixed timestep of 1/fps, as a numbered PNG sequence in dir
+// along with a manifest.json.
+func FramesMode(st *State, dir string, width, height int, fps float64, length time.Duration) int {
+	if fps <= 0 {
+		return log.FErrf("invalid -fps %g for -frames, should be > 0", fps)
+	}
+	if st.grow <= 0 && st.Canvas.Wind == nil && !st.cycle && st.Canvas.Fall == nil && st.Canvas.Snowfall == nil {
+		return log.FErrf("nothing to animate for -frames, use -grow, -wind, -season %s, -fall or -snow"+
+			" (or -save for a single image)", seasonCycle)
+	}
+	if length <= 0 {
+		length = st.grow
+	}
+	if length <= 0 && st.cycle {
+		length = seasonYear
+	}
+	if length <= 0 {
+		length = defaultFramesDuration
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return log.FErrf("failed to create frames directory: %v", err)
+	}
+	st.Canvas.Width = width
+	st.Canvas.Height = height
+	if err := st.Canvas.Generate(); err != nil {
+		return log.FErrf("failed to generate tree: %v", err)
+	}
+	// Both ends included, so the last frame is the fully grown tree.
+	n := int(math.Round(length.Seconds()*fps)) + 1
+	for i := range n {
+		t := time.Duration(float64(i) / fps * float64(time.Second))
+		st.Animate(t, t)
+		if i > 0 {
+			st.Canvas.StepFalling(1 / fps)
+		}
+		img := st.NewImage(width, height)
+		ptree.DrawTree(img, &st.Canvas, st.lines)
+		if err := SavePNG(filepath.Join(dir, fmt.Sprintf(framePattern, i+1)), img); err != nil {
+			return log.FErrf("failed to save frame %d: %v", i+1, err)
+		}
+	}
+	m := FramesManifest{
+		Pattern:  framePattern,
+		Frames:   n,
+		FPS:      fps,
+		Duration: length.String(),
+		Width:    width,
+		Height:   height,
+		Flags:    map[string]string{},
+		FFmpeg:   fmt.Sprintf("ffmpeg -framerate %g -i %s -c:v libx264 -pix_fmt yuv420p tree.mp4", fps, framePattern),
+	}
+	flag.Visit(func(f *flag.Flag) {
+		m.Flags[f.Name] = f.Value.String()
+	})
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return log.FErrf("failed to encode manifest: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0o644); err != nil { //nolint:gosec // not secret
+		return log.FErrf("failed to save manifest: %v", err)
+	}
+	log.Infof("Saved %d frames (%v at %g fps) in %s", n, length, fps, dir)
+	return 0
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"time"
+
+	"fortio.org/log"
+	"fortio.org/tbonsai/ptree"
+)
+
+// gifFinalHold is how many times longer the last (fully grown) frame of the animation is shown.
+const gifFinalHold = 10
+
+// GIFMode saves an animated GIF of a generated tree growing: branches appear level by
+// level in [ptree.Canvas.Branches] (breadth first) order, then the leaves bud.
+func GIFMode(st *State, filename string, width, height int) int {
+	if st.gifFrames < 1 {
+		return log.FErrf("invalid -gif-frames %d, should be at least 1", st.gifFrames)
+	}
+	st.Canvas.Width = width
+	st.Canvas.Height = height
+	if err := st.Canvas.Generate(); err != nil {
+		return log.FErrf("failed to generate tree: %v", err)
+	}
+	frames := make([]*image.RGBA, 0, st.gifFrames)
+	for i := 1; i <= st.gifFrames; i++ {
+		st.Canvas.Progress = float64(i) / float64(st.gifFrames)
+		st.Canvas.Growing = st.Canvas.Progress < 1
+		frames = append(frames, st.treeImage(width, height))
+	}
+	// The last frame has all the colors of the tree (browns and greens): the palette is
+	// built from it, index 0 being the transparent background.
+	p := newPalette(frames[len(frames)-1], 255)
+	pal := p.gifPalette()
+	delay := int(st.gifDelay / (10 * time.Millisecond)) // in 100ths of a second
+	anim := &gif.GIF{LoopCount: st.gifLoop}
+	for i, img := range frames {
+		anim.Image = append(anim.Image, gifFrame(img, p, pal))
+		d := delay
+		if i == len(frames)-1 {
+			d *= gifFinalHold
+		}
+		anim.Delay = append(anim.Delay, d)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return log.FErrf("failed to save GIF: %v", err)
+	}
+	defer f.Close()
+	if err = gif.EncodeAll(f, anim); err != nil {
+		return log.FErrf("failed to save GIF: %v", err)
+	}
+	return 0
+}
+
+// treeImage draws the current tree (and growth progress) on a new RGBA image.
+func (st *State) treeImage(width, height int) *image.RGBA {
+	timg := st.NewImage(width, height)
+	ptree.DrawTree(timg, &st.Canvas, st.lines)
+	if img, ok := timg.(*image.RGBA); ok {
+		return img
+	}
+	// Lines are drawn on NRGBA.
+	img := image.NewRGBA(timg.Bounds())
+	draw.Draw(img, img.Bounds(), timg, image.Point{}, draw.Src)
+	return img
+}
+
+// gifPalette returns the GIF palette for p: index 0 is the transparent background and the
+// colors of p follow (shifted by 1).
+func (p *palette) gifPalette() color.Palette {
+	pal := color.Palette{color.Transparent}
+	for _, c := range p.colors {
+		pal = append(pal, c)
+	}
+	return pal
+}
+
+// gifFrame converts an image to the palette p (shifted by 1 for the transparent index 0 of pal).
+func gifFrame(img *image.RGBA, p *palette, pal color.Palette) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(b, pal)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ok := opaque(img.RGBAAt(x, y))
+			if !ok {
+				continue
+			}
+			k := rgb555(c)
+			idx, found := p.index[k]
+			if !found { // color not in the last frame
+				idx = p.closest(c)
+				p.index[k] = idx
+			}
+			out.SetColorIndex(x, y, idx+1)
+		}
+	}
+	return out
+}
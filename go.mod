@@ -10,6 +10,7 @@ require (
 	fortio.org/safecast v1.2.0
 	fortio.org/terminal v0.65.4
 	golang.org/x/image v0.44.0
+	golang.org/x/sys v0.47.0
 )
 
 require (
@@ -19,6 +20,5 @@ require (
 	github.com/kortschak/goroutine v1.1.3 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/crypto/x509roots/fallback v0.0.0-20250406160420-959f8f3db0fb // indirect
-	golang.org/x/sys v0.47.0 // indirect
 	golang.org/x/term v0.45.0 // indirect
 )
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"fortio.org/log"
+)
+
+// Graphics protocols for -graphics (blocks is the default half blocks rendering).
+const (
+	GraphicsAuto   = "auto"
+	GraphicsKitty  = "kitty"
+	GraphicsITerm  = "iterm"
+	GraphicsSixel  = "sixel"
+	GraphicsBlocks = "blocks"
+)
+
+var graphicsNames = []string{GraphicsAuto, GraphicsKitty, GraphicsITerm, GraphicsSixel, GraphicsBlocks}
+
+// graphicsQueryTimeout is how long to wait for the terminal to answer the detection queries.
+const graphicsQueryTimeout = time.Second
+
+const (
+	// Kitty graphics protocol query (a=q) of a 1x1 pixel, supporting terminals reply with OK for that id.
+	kittyQuery = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+	kittyReply = "\x1b_Gi=31;OK"
+	// Primary Device Attributes: all terminals answer, 4 in the list means sixel support.
+	deviceAttributesQuery = "\x1b[c"
+)
+
+var (
+	deviceAttributesReply = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+	// Any Kitty graphics reply (OK or error), up to the string terminator.
+	kittyReplies = regexp.MustCompile(`\x1b_G[^\x1b]*\x1b\\`)
+)
+
+// ParseGraphics validates a -graphics value.
+func ParseGraphics(name string) (string, error) {
+	if slices.Contains(graphicsNames, name) {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown graphics protocol %q, should be one of %v", name, graphicsNames)
+}
+
+// SetGraphics selects the graphics protocol (kitty, iterm, sixel or blocks).
+func (st *State) SetGraphics(mode string) {
+	st.kitty = mode == GraphicsKitty
+	st.iterm = mode == GraphicsITerm
+	st.sixel = mode == GraphicsSixel
+}
+
+// DetectGraphics returns the best graphics protocol supported by the terminal: kitty,
+// iterm (from TERM_PROGRAM, the protocol can't be queried), sixel or else half blocks.
+// The terminal must be in raw mode (see [ansipixels.AnsiPixels.Open]) for the queries
+// (a Kitty graphics query followed by Primary Device Attributes), otherwise (e.g with -exit)
+// only the environment is used. So it is with -fps 0 as blocking reads can't time out (on a
+// terminal that doesn't answer).
+func (st *State) DetectGraphics(raw bool) string {
+	raw = raw && st.ap.FPS > 0
+	var kitty, sixel bool
+	if raw {
+		kitty, sixel = st.queryGraphics()
+	} else {
+		// Env hints only.
+		term := os.Getenv("TERM")
+		kitty = os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || os.Getenv("TERM_PROGRAM") == "ghostty"
+	}
+	iterm := false
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "mintty":
+		iterm = true
+	}
+	iterm = iterm || os.Getenv("LC_TERMINAL") == "iTerm2"
+	mode := GraphicsBlocks
+	switch {
+	case kitty:
+		mode = GraphicsKitty
+	case iterm:
+		mode = GraphicsITerm
+	case sixel:
+		mode = GraphicsSixel
+	}
+	log.Infof("Graphics auto detection: using %s (kitty %v, iterm %v, sixel %v, queried %v)", mode, kitty, iterm, sixel, raw)
+	return mode
+}
+
+// queryGraphics sends the Kitty graphics and device attributes queries and reads the replies
+// until the device attributes one arrives (replies come in order so Kitty's would be before)
+// or the timeout. Other input (keys typed meanwhile) is kept for [State.Tick].
+func (st *State) queryGraphics() (kitty, sixel bool) {
+	st.ap.WriteString(kittyQuery + deviceAttributesQuery)
+	_ = st.ap.Out.Flush()
+	var replies []byte
+	var da [][]byte
+	deadline := time.Now().Add(graphicsQueryTimeout)
+	defer st.ap.ChangeFPS(st.ap.FPS) // back to the frame read timeout
+	for da == nil {
+		left := time.Until(deadline)
+		if left <= 0 {
+			break
+		}
+		st.ap.SharedInput.ChangeTimeout(left)
+		n, err := st.ap.ReadOrResizeOrSignalOnce()
+		if err != nil {
+			log.Warnf("Error reading graphics query replies: %v", err)
+			break
+		}
+		replies = append(replies, st.ap.Data[:n]...)
+		da = deviceAttributesReply.FindSubmatch(replies)
+	}
+	if da == nil {
+		log.Warnf("No device attributes reply within %v (got %q)", graphicsQueryTimeout, replies)
+	}
+	kitty = bytes.Contains(replies, []byte(kittyReply))
+	if da != nil {
+		sixel = slices.Contains(strings.Split(string(da[1]), ";"), "4")
+	}
+	typed := deviceAttributesReply.ReplaceAll(kittyReplies.ReplaceAll(replies, nil), nil)
+	st.typed = append(st.typed, typed...)
+	return kitty, sixel
+}
@@ -13,6 +13,7 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
 	"strings"
 	"time"
@@ -39,8 +40,28 @@ type State struct {
 	last   time.Time
 	lines  bool
 	kitty  bool
+	sixel  bool
+	iterm  bool
+	glyphs bool
+	brail  bool // braille dots
+	ascii  bool // cbonsai style characters
+	colors bool // colored -ascii output
+	exit   bool // single draw (plain text for -ascii)
 	width  int
 	height int
+	start  time.Time     // for animations (wind)
+	grow   time.Duration // growth animation duration (0 = draw the tree fully grown right away)
+	grown  time.Time     // when the growth of the current tree started
+	cycle  bool          // -season cycle
+	ticked time.Time     // previous Tick, for the leaf fall
+	typed  []byte        // keys typed during the graphics detection, for the next Tick
+	// Last animation frame sent with an image protocol (see [State.RenderFrame]).
+	rendered time.Time
+	shown    []byte // its pixels
+	// Animated GIF (-save *.gif) parameters.
+	gifFrames int
+	gifDelay  time.Duration
+	gifLoop   int
 	ptree.Canvas
 }
 
@@ -100,17 +121,102 @@ func KittyImage(w io.Writer, img image.Image, termWidth, termHeight int) error {
 	return nil
 }
 
+// ITermImage sends an image using the iTerm2 inline images protocol (also supported by
+// WezTerm and mintty), fit in the given cells area preserving the aspect ratio.
+// https://iterm2.com/documentation-images.html
+func ITermImage(w io.Writer, img image.Image, termWidth, termHeight int) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	// doNotMoveCursor=1 so an image reaching the bottom of the screen doesn't scroll it.
+	fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1;doNotMoveCursor=1:",
+		buf.Len(), termWidth, termHeight)
+	fmt.Fprint(w, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	fmt.Fprint(w, "\a")
+	return nil
+}
+
+// LoadLSystem returns the built-in grammar of that name or reads and parses the rule file.
+func LoadLSystem(nameOrFile string) (*ptree.LSystem, error) {
+	if l := ptree.BuiltinLSystem(nameOrFile); l != nil {
+		return l, nil
+	}
+	f, err := os.Open(nameOrFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	l, err := ptree.ParseLSystem(nameOrFile, f)
+	if err != nil {
+		return nil, err
+	}
+	return l, l.Validate()
+}
+
+// ListSpecies writes the species presets names and descriptions.
+func ListSpecies(w io.Writer) {
+	for _, s := range ptree.SpeciesList {
+		fmt.Fprintf(w, "%-10s %s\n", s.Name, s.Description)
+	}
+}
+
+// ApplySpecies sets the species preset values for the flags that weren't explicitly set on the command line.
+func ApplySpecies(
+	s *ptree.Species, depth *int, spread, trunkWidth, trunkHeight, leafSize, gravity *float64, trunkColor, leafShape *string,
+) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["depth"] {
+		*depth = s.Depth
+	}
+	if !set["spread"] {
+		*spread = s.Spread
+	}
+	if !set["trunk-width"] {
+		*trunkWidth = s.TrunkWidthPct
+	}
+	if !set["trunk-height"] {
+		*trunkHeight = s.TrunkHeightPct
+	}
+	if !set["leaf-size"] {
+		*leafSize = s.LeafSize
+	}
+	if !set["gravity"] {
+		*gravity = s.Droop
+	}
+	if !set["color"] {
+		*trunkColor = s.TrunkColor // empty keeps the default.
+	}
+	if !set["leaf-shape"] {
+		*leafShape = s.LeafShape.String()
+	}
+}
+
+// NewImage returns a new image of the type needed by [ptree.DrawTree] (NRGBA for -lines).
+func (st *State) NewImage(width, height int) draw.Image {
+	if st.lines {
+		return image.NewNRGBA(image.Rect(0, 0, width, height))
+	}
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+// imagePix returns the pixels of an image made by [State.NewImage].
+func imagePix(img draw.Image) []byte {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n.Pix
+	}
+	return img.(*image.RGBA).Pix
+}
+
 func PNGMode(st *State, filename string, width, height int) int {
 	// Save a single generated tree as a PNG image and exit
 	st.Canvas.Width = width
 	st.Canvas.Height = height
-	st.Canvas.Generate()
-	var img draw.Image
-	if st.lines {
-		img = image.NewNRGBA(image.Rect(0, 0, width, height))
-	} else {
-		img = image.NewRGBA(image.Rect(0, 0, width, height))
+	if err := st.Canvas.Generate(); err != nil {
+		return log.FErrf("failed to generate tree: %v", err)
 	}
+	img := st.NewImage(width, height)
 	ptree.DrawTree(img, &st.Canvas, st.lines)
 	if err := SavePNG(filename, img); err != nil {
 		return log.FErrf("failed to save PNG: %v", err)
@@ -118,6 +224,37 @@ func PNGMode(st *State, filename string, width, height int) int {
 	return 0
 }
 
+// SVGMode saves a single generated tree as an SVG image.
+func SVGMode(st *State, filename string, width, height int) int {
+	st.Canvas.Width = width
+	st.Canvas.Height = height
+	if err := st.Canvas.Generate(); err != nil {
+		return log.FErrf("failed to generate tree: %v", err)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return log.FErrf("failed to save SVG: %v", err)
+	}
+	defer f.Close()
+	if err = ptree.WriteSVG(f, &st.Canvas, st.lines); err != nil {
+		return log.FErrf("failed to save SVG: %v", err)
+	}
+	return 0
+}
+
+// SaveMode saves a single generated tree, in the format picked from the file extension
+// (.svg, .gif animation, otherwise PNG), and exits.
+func SaveMode(st *State, filename string, width, height int) int {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".svg":
+		return SVGMode(st, filename, width, height)
+	case ".gif":
+		return GIFMode(st, filename, width, height)
+	default:
+		return PNGMode(st, filename, width, height)
+	}
+}
+
 func Main() int {
 	truecolorDefault := ansipixels.DetectColorMode().TrueColor
 	fTrueColor := flag.Bool("truecolor", truecolorDefault,
@@ -131,19 +268,99 @@ func Main() int {
 	fRainbow := flag.Bool("rainbow", false, "Use random colors for each branch instead of depth-based brown gradient")
 	fLeaves := flag.Bool("leaves", false, "Draw leaves at branch endpoints")
 	fLeafSize := flag.Float64("leaf-size", 1.0, "Leaf size multiplier")
+	fLeafShape := flag.String("leaf-shape", "triangle", "Leaf `shape`: "+strings.Join(ptree.LeafShapeNames(), ", ")+
+		" (-species sets its own, small leaves use simpler shapes)")
 	fAuto := duration.Flag("auto", 0, "If >0, automatically redraw a new tree at this `interval` and no user input is needed")
 	fSeed := flag.Uint64("seed", 0, "Seed for random number generation. 0 means different random each run")
 	fLines := flag.Bool("lines", false, "Use simple line drawing instead of polygon mode (default is polygon)")
-	fSave := flag.String("save", "", "If set to a `file name`, saves one generated tree as a PNG image to that file and exits")
+	fSave := flag.String("save", "", "If set to a `file name`, saves one generated tree as a PNG image to that file and exits"+
+		" (SVG vector image if the file ends with .svg, animation of the tree growing if it ends with .gif)")
+	fFrames := flag.String("frames", "", "If set to a `directory`, saves the animation (-grow, -wind, -season cycle, -fall, -snow)"+
+		" of one generated tree as a numbered PNG sequence at -fps (and a manifest.json for assembling a video offline) and exits")
+	fFramesDuration := duration.Flag("frames-duration", 0,
+		"`Duration` of the -frames sequence (default is the -grow duration, or a year for -season cycle, or 5s)")
+	fGIFFrames := flag.Int("gif-frames", 30, "Number of frames of the -save .gif growth animation")
+	fGIFDelay := duration.Flag("gif-delay", 100*time.Millisecond,
+		"`Delay` between frames of the -save .gif animation (the fully grown tree is shown 10 times longer)")
+	fGIFLoop := flag.Int("gif-loop", 0, "Loop `count` of the -save .gif animation (0 loops forever, -1 plays once)")
+	fGraphics := flag.String("graphics", GraphicsBlocks, "Graphics `protocol`: "+strings.Join(graphicsNames, ", ")+
+		" (auto detects the best one supported by the terminal, blocks are the default half blocks)")
 	fKitty := flag.Bool("kitty", false, "Use Kitty graphics protocol for high-res images (resizable, regeneratable)")
+	fASCII := flag.Bool("ascii", false,
+		"Draw the tree with ASCII characters (/|\\_~ and & for leaves), e.g for serial consoles or text files")
+	fASCIIColor := flag.Bool("ascii-color", isTerminal(os.Stdout),
+		"Use colors in -ascii mode (default is true if stdout is a terminal)")
+	fBraille := flag.Bool("braille", false,
+		"Draw the tree with Braille dots (2x4 pixels per cell) instead of half blocks, best with -lines")
+	fITerm := flag.Bool("iterm", false,
+		"Use iTerm2 inline images protocol for high-res images (iTerm2, WezTerm, mintty), resizable and regeneratable like -kitty")
+	fSixel := flag.Bool("sixel", false,
+		"Use Sixel graphics for high-res images (xterm, foot, mlterm, WezTerm...), resizable and regeneratable like -kitty")
+	fGlyphs := flag.Bool("glyphs", false, "Draw the tree with full block and triangle glyphs (◢◣◤◥) instead of half blocks")
 	fWidth := flag.Int("width", 1280, "Width of the generated tree image when using Kitty mode or saving to PNG")
 	fHeight := flag.Int("height", 720, "Height of the generated tree image when using Kitty mode or saving to PNG")
 	fDepth := flag.Int("depth", 6, "Tree depth (number of branch levels)")
 	fTrunkWidth := flag.Float64("trunk-width", 7.0, "Starting width of the trunk as `percentage` of image width")
 	fTrunkHeight := flag.Float64("trunk-height", 35.0, "Trunk height as `percentage` of available height")
 	fSpread := flag.Float64("spread", 1.0, "Branch angle spread multiplier (< 1.0 narrower, > 1.0 wider)")
+	fLSystem := flag.String("lsystem", "",
+		"Generate the tree from an L-system grammar: built-in `name` ("+strings.Join(ptree.BuiltinLSystemNames(), ", ")+
+			") or rule file path")
+	fAlgo := flag.String("algo", "branch",
+		"Tree generation `algorithm`: branch (recursive branching) or colonize (space colonization)")
+	fAttractors := flag.Int("attractors", 400, "Number of attraction points for -algo colonize")
+	fKillDistance := flag.Float64("kill-distance", 3.0,
+		"Distance at which attraction points are reached for -algo colonize, as `percentage` of image width")
+	fCrown := flag.String("crown", "ellipse", "Crown `shape` for -algo colonize: ellipse, cone or dome")
+	fSpecies := flag.String("species", "",
+		"Species `name` preset for depth, spread, trunk, colors, leaves and branching (individual flags override it),"+
+			" see `tbonsai species` for the list")
+	fCurves := flag.Bool("curves", false, "Draw branches as smooth tapered curves instead of straight segments")
+	fGravity := flag.Float64("gravity", 0, "How much branches bend down with depth and length (0 none, 1+ weeping)")
+	fSunAngle := flag.Float64("sun-angle", 90,
+		"Direction of the sun in `degrees` (90 is above, 0 right, 180 left) for -phototropism and -light, no effect without them")
+	fPhototropism := flag.Float64("phototropism", 0, "How much branches bend toward the sun (e.g 1, 0 none), see -sun-angle")
+	fBark := flag.String("bark", "flat", "Bark texture `style` of the branches at high resolution: "+
+		strings.Join(ptree.BarkNames(), ", "))
+	fLight := flag.Float64("light", 0, "Directional lighting `strength` from the -sun-angle (0 flat colors to 1 shaded"+
+		" branches and leaves, lit on the sun side)")
+	fWind := flag.Float64("wind", 0, "Wind `strength` making branches sway and leaves flutter (e.g 1), gusts depend on -seed")
+	fRoots := flag.Int("roots", 0, "Number of surface roots (nebari) spreading from the trunk base")
+	fFlowers := flag.Bool("flowers", false, "Draw five petal flowers (sakura style) at the tip of the terminal branches")
+	fFlowerColor := flag.String("flower-color", "", "Flowers (and spring blossoms) `hex color` (default #FFAAC3 sakura pink)")
+	fFlowerSize := flag.Float64("flower-size", 1.0, "Flower size multiplier")
+	fFlowerDensity := flag.Int("flower-density", 0, "Number of flowers per terminal branch (0 is auto based on resolution)")
+	fFruit := flag.String("fruit", "", "Hang fruits of this `kind` ("+strings.Join(ptree.FruitNames(), ", ")+") from the branches")
+	fFruitDensity := flag.Float64("fruit-density", 0.1, "Fraction (0 to 1) of the terminal and near terminal branches with a fruit")
+	fSeason := flag.String("season", "", "Foliage `season`: spring (sparse light leaves and blossoms), summer, autumn,"+
+		" winter (bare branches) or cycle to go through the year, implies -leaves")
+	fSnow := flag.Bool("snow", false, "Snow in -season winter (the default with it) or cycle: on the branches and, in"+
+		" the animated modes, falling and piling up on the branches and the -pot or the bottom")
+	fFall := flag.Float64("fall", 0, "Leaves falling from the tree per second (`rate`) in the animated modes, implies -leaves")
+	fFallMax := flag.Int("fall-max", 100, "Maximum number of leaves falling at the same time for -fall")
+	fGrow := duration.Flag("grow", 0,
+		"If >0, animate the tree growing branch by branch over this `duration` (any key skips to the full tree)")
 	fExit := flag.Bool("exit", false, "Exit immediately after drawing the tree once and saving ansi/kitty image if applicable")
+	cli.MaxArgs = 1
+	cli.ArgsHelp = "[species]"
 	cli.Main()
+	if flag.NArg() == 1 {
+		if flag.Arg(0) != "species" {
+			cli.ErrUsage("Unknown command %q (only `species` is supported)", flag.Arg(0))
+			return 1 // not typically reached
+		}
+		ListSpecies(os.Stdout)
+		return 0
+	}
+	var species *ptree.Species
+	if *fSpecies != "" {
+		var err error
+		species, err = ptree.FindSpecies(*fSpecies)
+		if err != nil {
+			return log.FErrf("invalid -species: %v", err)
+		}
+		ApplySpecies(species, fDepth, fSpread, fTrunkWidth, fTrunkHeight, fLeafSize, fGravity, fTrunkColor, fLeafShape)
+	}
 	if *fCpuprofile != "" {
 		f, err := os.Create(*fCpuprofile)
 		if err != nil {
@@ -157,6 +374,12 @@ func Main() int {
 		defer pprof.StopCPUProfile()
 	}
 	rnd := rand.New(*fSeed)
+	if *fSnow && *fSeason == "" {
+		*fSeason = ptree.Winter.String()
+	}
+	if *fSeason != "" || *fFall > 0 {
+		*fLeaves = true // the seasons (and falling leaves) are about the foliage
+	}
 	if *fTrunkColor == "" {
 		if *fLeaves {
 			*fTrunkColor = "#654321" // default dark brown
@@ -171,39 +394,163 @@ func Main() int {
 	ap := ansipixels.NewAnsiPixels(*fFPS)
 	ap.TrueColor = *fTrueColor
 	st := &State{
-		ap:     ap,
-		pot:    *fPot,
-		auto:   *fAuto,
-		lines:  *fLines,
-		kitty:  *fKitty,
-		width:  *fWidth,
-		height: *fHeight,
+		ap:        ap,
+		pot:       *fPot,
+		auto:      *fAuto,
+		lines:     *fLines,
+		kitty:     *fKitty,
+		sixel:     *fSixel,
+		iterm:     *fITerm,
+		glyphs:    *fGlyphs,
+		brail:     *fBraille,
+		ascii:     *fASCII,
+		colors:    *fASCIIColor,
+		exit:      *fExit,
+		width:     *fWidth,
+		height:    *fHeight,
+		start:     time.Now(),
+		grow:      *fGrow,
+		gifFrames: *fGIFFrames,
+		gifDelay:  *fGIFDelay,
+		gifLoop:   *fGIFLoop,
 		Canvas: ptree.Canvas{
-			TrunkColor:     tcolor.ToRGB(c.Decode()),
-			Rainbow:        *fRainbow,
-			Leaves:         *fLeaves,
-			LeafSize:       *fLeafSize,
-			MaxDepth:       *fDepth,
-			Rand:           rnd,
-			Spread:         *fSpread,
-			TrunkWidthPct:  *fTrunkWidth,
-			TrunkHeightPct: *fTrunkHeight,
+			TrunkColor:      tcolor.ToRGB(c.Decode()),
+			Rainbow:         *fRainbow,
+			Leaves:          *fLeaves,
+			LeafSize:        *fLeafSize,
+			MaxDepth:        *fDepth,
+			Rand:            rnd,
+			Spread:          *fSpread,
+			TrunkWidthPct:   *fTrunkWidth,
+			TrunkHeightPct:  *fTrunkHeight,
+			Attractors:      *fAttractors,
+			KillDistancePct: *fKillDistance,
+			Species:         species,
+			Curves:          *fCurves,
+			Gravity:         *fGravity,
+			LightDirection:  ptree.LightFromAngle(*fSunAngle),
+			Phototropism:    *fPhototropism,
+			Lighting:        *fLight,
+			Roots:           *fRoots,
+			Snow:            *fSnow,
+			Flowers:         *fFlowers,
+			FlowerSize:      *fFlowerSize,
+			FlowerDensity:   *fFlowerDensity,
+			FruitDensity:    *fFruitDensity,
 		},
 	}
+	st.Canvas.Algorithm, err = ptree.ParseAlgorithm(*fAlgo)
+	if err != nil {
+		return log.FErrf("invalid -algo: %v", err)
+	}
+	st.Canvas.CrownShape, err = ptree.ParseCrownShape(*fCrown)
+	if err != nil {
+		return log.FErrf("invalid -crown: %v", err)
+	}
+	st.Canvas.LeafShape, err = ptree.ParseLeafShape(*fLeafShape)
+	if err != nil {
+		return log.FErrf("invalid -leaf-shape: %v", err)
+	}
+	st.Canvas.Bark, err = ptree.ParseBarkStyle(*fBark)
+	if err != nil {
+		return log.FErrf("invalid -bark: %v", err)
+	}
+	if *fFlowerColor != "" {
+		fc, err := tcolor.FromString(*fFlowerColor)
+		if err != nil {
+			return log.FErrf("invalid flower color: %v", err)
+		}
+		st.Canvas.FlowerColor = tcolor.ToRGB(fc.Decode())
+	}
+	if *fFruit != "" {
+		st.Canvas.Fruit, err = ptree.FindFruit(*fFruit)
+		if err != nil {
+			return log.FErrf("invalid -fruit: %v", err)
+		}
+	}
+	switch *fSeason {
+	case "":
+	case seasonCycle:
+		st.cycle = true
+		st.Canvas.Season = ptree.SeasonAt(0)
+	default:
+		st.Canvas.Season, err = ptree.ParseSeason(*fSeason)
+		if err != nil {
+			return log.FErrf("invalid -season: %v (or %s)", err, seasonCycle)
+		}
+	}
+	if *fSnow && !st.cycle && st.Canvas.Season != ptree.Winter {
+		return log.FErrf("-snow is only for -season %s (or %s), not %s", ptree.Winter, seasonCycle, st.Canvas.Season)
+	}
+	if *fLight < 0 || *fLight > 1 {
+		return log.FErrf("invalid -light %g, should be between 0 and 1", *fLight)
+	}
+	if *fWind > 0 {
+		// Separate stream from the tree one so the tree for a given seed is the same with or without wind.
+		st.Canvas.Wind = ptree.NewWind(*fWind, rand.NewIdx(1, *fSeed))
+	}
+	if *fFall > 0 {
+		if *fFallMax < 1 {
+			return log.FErrf("invalid -fall-max %d, should be at least 1", *fFallMax)
+		}
+		st.Canvas.Fall = ptree.NewLeafFall(*fFall, *fFallMax, rand.NewIdx(2, *fSeed))
+	}
+	if *fSnow && *fSave == "" && !*fExit {
+		// Falling snow in the animated modes, the still images have the full snow caps.
+		st.Canvas.Snowfall = ptree.NewSnowfall(snowRate, snowMaxFlakes, rand.NewIdx(3, *fSeed))
+	}
+	if *fLSystem != "" {
+		st.Canvas.LSystem, err = LoadLSystem(*fLSystem)
+		if err != nil {
+			return log.FErrf("invalid L-system %q: %v", *fLSystem, err)
+		}
+	}
+	graphics, err := ParseGraphics(*fGraphics)
+	if err != nil {
+		return log.FErrf("invalid -graphics: %v", err)
+	}
+	modes := 0
+	for _, m := range []bool{st.kitty, st.iterm, st.sixel, st.glyphs, st.ascii, st.brail, graphics != GraphicsBlocks} {
+		if m {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return log.FErrf("only one of -graphics, -kitty, -iterm, -sixel, -glyphs, -ascii and -braille can be used")
+	}
+	if graphics != GraphicsAuto && graphics != GraphicsBlocks {
+		st.SetGraphics(graphics) // same as the individual flags
+	}
+	if *fFrames != "" {
+		if *fSave != "" {
+			return log.FErrf("only one of -frames and -save can be used")
+		}
+		return FramesMode(st, *fFrames, *fWidth, *fHeight, *fFPS, *fFramesDuration)
+	}
 	if *fSave != "" {
-		return PNGMode(st, *fSave, *fWidth, *fHeight)
+		return SaveMode(st, *fSave, *fWidth, *fHeight)
 	}
 	if *fExit { //nolint:nestif // well...
 		st.tree = true
+		st.grow = 0 // only drawing once, so fully grown
+		if graphics == GraphicsAuto {
+			st.SetGraphics(st.DetectGraphics(false))
+		}
 		ap.W, ap.H, err = ansipixels.NonRawTerminalSize()
 		if err != nil {
-			return log.FErrf("failed to get terminal size: %v", err)
+			if !st.ascii {
+				return log.FErrf("failed to get terminal size: %v", err)
+			}
+			err = nil // plain text can use the default 80x24
 		}
 	} else {
 		if err = ap.Open(); err != nil {
 			return 1 // error already logged
 		}
 		defer ap.Restore()
+		if graphics == GraphicsAuto {
+			st.SetGraphics(st.DetectGraphics(true))
+		}
 		if st.auto > 0 {
 			st.tree = true
 			ap.HideCursor()
@@ -211,7 +558,11 @@ func Main() int {
 		ap.SyncBackgroundColor()
 	}
 	ap.OnResize = st.OnResize
-	_ = ap.OnResize() // initial draw.
+	if st.exit && st.ascii {
+		st.DrawTree() // plain text, no screen clearing
+	} else {
+		_ = ap.OnResize() // initial draw.
+	}
 	if !*fExit {
 		ap.AutoSync = false // keeps cursor blinking.
 		err = ap.FPSTicks(st.Tick)
@@ -251,8 +602,20 @@ func (st *State) OnResize() error {
 }
 
 func (st *State) Tick() bool {
-	if st.auto > 0 && time.Since(st.last) >= st.auto {
+	now := time.Now()
+	if st.tree && !st.ticked.IsZero() {
+		st.Canvas.StepFalling(now.Sub(st.ticked).Seconds())
+	}
+	st.ticked = now
+	if len(st.typed) > 0 {
+		st.ap.Data = append(st.typed, st.ap.Data...)
+		st.typed = nil
+	}
+	if st.auto > 0 && time.Since(st.last) >= st.grow+st.auto {
 		st.DrawTree()
+	} else if st.tree && (st.Canvas.Wind != nil || st.Canvas.Growing || st.Canvas.Fall != nil || st.Canvas.Snowfall != nil ||
+		(st.cycle && seasonAt(time.Since(st.start)) != st.Canvas.Season)) {
+		st.RenderFrame() // animate the current tree
 	}
 	if len(st.ap.Data) == 0 {
 		return true
@@ -263,26 +626,42 @@ func (st *State) Tick() bool {
 		log.Infof("Exiting on %q", c)
 		return false
 	case 't', 'T':
+		if st.Canvas.Growing {
+			st.SkipGrowth()
+			return true
+		}
 		if !st.tree {
 			st.ap.HideCursor()
 			st.tree = true
 		}
 		st.DrawTree()
 	default:
-		// Do something
+		if st.Canvas.Growing {
+			st.SkipGrowth()
+		}
 	}
 	return true
 }
 
+// SkipGrowth ends the growth animation and shows the finished tree.
+func (st *State) SkipGrowth() {
+	st.grown = time.Now().Add(-st.grow)
+	st.Render()
+}
+
+// potSize returns the center column and the radius of the pot base (1/4th of the width w),
+// its rim going from cx-radius-1 to cx+radius+1.
+func potSize(w int) (cx, radius int) {
+	return (w - 1) / 2, w / 8
+}
+
 func (st *State) Pot() {
 	if !st.pot {
 		return
 	}
 	w := st.ap.W
 	h := st.ap.H
-	cx := (w - 1) / 2
-	// pot base 1/4th of the width
-	radius := w / 8
+	cx, radius := potSize(w)
 	// Feet
 	st.ap.WriteAtStr(cx-radius-1, h-3, "╲")
 	st.ap.WriteAtStr(cx+radius+1, h-3, "╱")
@@ -303,32 +682,137 @@ func (st *State) DrawTree() {
 		dy = 3
 	}
 	usableHeight := st.ap.H - dy
-	if st.kitty {
+	if st.kitty || st.iterm {
 		aspectRatio := float64(st.ap.W) / float64(usableHeight*2)
 		// Use fixed dimensions for Kitty mode
 		st.Canvas.Height = st.height
 		// adjust aspect ratio for terminal cells
 		st.Canvas.Width = safecast.MustRound[int](float64(st.Canvas.Height) * aspectRatio)
+	} else if st.sixel {
+		// Image at the terminal resolution.
+		rows := usableHeight
+		if dy == 0 {
+			rows-- // so the cursor after the image doesn't scroll the screen
+		}
+		cellW, cellH := CellPixelSize(st.ap.W, st.ap.H)
+		st.Canvas.Width = st.ap.W * cellW
+		st.Canvas.Height = rows * cellH
+	} else if st.glyphs {
+		st.Canvas.Width = st.ap.W * GlyphCellW
+		st.Canvas.Height = usableHeight * GlyphCellH
+	} else if st.brail {
+		st.Canvas.Width = st.ap.W * BrailleCellW
+		st.Canvas.Height = usableHeight * BrailleCellH
 	} else {
 		// Use terminal dimensions for ansipixels mode
 		st.Canvas.Width = st.ap.W
 		st.Canvas.Height = 2 * usableHeight
 	}
-	st.Canvas.Generate()
-	var img draw.Image
-	if st.lines {
-		img = image.NewNRGBA(image.Rect(0, 0, st.Canvas.Width, st.Canvas.Height))
-	} else {
-		img = image.NewRGBA(image.Rect(0, 0, st.Canvas.Width, st.Canvas.Height))
+	st.Canvas.Pot = nil
+	if st.pot {
+		// The rim is in the last row of the image.
+		cx, radius := potSize(st.ap.W)
+		scale := float64(st.Canvas.Width) / float64(st.ap.W)
+		st.Canvas.Pot = &ptree.Rim{Left: float64(cx-radius-1) * scale, Right: float64(cx+radius+2) * scale}
 	}
+	if err := st.Canvas.Generate(); err != nil {
+		log.Errf("failed to generate tree: %v", err)
+	}
+	st.grown = time.Now()
+	st.Render()
+	st.last = time.Now()
+}
+
+// seasonCycle is the -season value to go through the seasons, each lasting a quarter of seasonYear.
+const (
+	seasonCycle = "cycle"
+	seasonYear  = 20 * time.Second
+)
+
+// Snowfall (-snow in the animated modes) flakes per second per 100 pixels of width and maximum
+// number of flakes in the air.
+const (
+	snowRate      = 15
+	snowMaxFlakes = 600
+)
+
+// seasonAt returns the season after elapsed time in -season cycle mode.
+func seasonAt(elapsed time.Duration) ptree.Season {
+	return ptree.SeasonAt(float64(elapsed) / float64(seasonYear))
+}
+
+// Animate sets the animation state of the tree: elapsed is the time since the start (for the
+// wind) and growing the time since the tree started growing (see -grow).
+func (st *State) Animate(elapsed, growing time.Duration) {
+	if st.Canvas.Wind != nil {
+		st.Canvas.Time = elapsed.Seconds()
+	}
+	if st.cycle {
+		st.Canvas.Season = seasonAt(elapsed)
+	}
+	st.Canvas.Growing = false
+	if st.grow > 0 {
+		st.Canvas.Progress = float64(growing) / float64(st.grow)
+		st.Canvas.Growing = st.Canvas.Progress < 1
+	}
+}
+
+// Render draws the current tree (at the current animation time if applicable).
+func (st *State) Render() {
+	st.render(false)
+}
+
+// maxImageFPS caps the animations with the image protocols (-kitty, -iterm, -sixel) as each
+// frame is a full PNG or Sixel encode.
+const maxImageFPS = 15
+
+// RenderFrame renders the next frame of the animation of the current tree: at most maxImageFPS
+// times per second with the image protocols, and only when the image changed.
+func (st *State) RenderFrame() {
+	if st.imageProtocol() && time.Since(st.rendered) < time.Second/maxImageFPS {
+		return
+	}
+	st.render(true)
+}
+
+// imageProtocol returns true for the graphics modes sending a full (encoded) image per frame.
+func (st *State) imageProtocol() bool {
+	return st.kitty || st.iterm || st.sixel
+}
+
+func (st *State) render(frame bool) {
+	var dy int
+	if st.pot {
+		dy = 3
+	}
+	st.Animate(time.Since(st.start), time.Since(st.grown))
+	if st.ascii {
+		st.RenderASCII()
+		return
+	}
+	img := st.NewImage(st.Canvas.Width, st.Canvas.Height)
 	ptree.DrawTree(img, &st.Canvas, st.lines)
+	if st.imageProtocol() {
+		pix := imagePix(img)
+		if frame && bytes.Equal(pix, st.shown) {
+			return // same as on screen
+		}
+		st.shown = append(st.shown[:0], pix...)
+		st.rendered = time.Now()
+	}
 
 	st.ap.StartSyncMode()
 	st.ap.ClearScreen()
-	st.Pot()
+	if !st.iterm {
+		st.Pot()
+	}
 	if st.kitty {
 		st.ap.MoveCursor(0, 0)
 		_ = KittyImage(st.ap.Out, img, st.ap.W, st.ap.H-dy)
+	} else if st.iterm {
+		st.ap.MoveCursor(0, 0)
+		_ = ITermImage(st.ap.Out, img, st.ap.W, st.ap.H-dy)
+		st.Pot() // after so the text is drawn on top of the image cells
 	} else {
 		// Convert NRGBA to RGBA if needed
 		var showImg *image.RGBA
@@ -338,8 +822,18 @@ func (st *State) DrawTree() {
 		} else {
 			showImg = img.(*image.RGBA)
 		}
-		_ = st.ap.ShowScaledImage(showImg)
+		switch {
+		case st.sixel:
+			// The previous image was erased by ClearScreen above.
+			st.ap.MoveCursor(0, 0)
+			_ = SixelImage(st.ap.Out, showImg)
+		case st.glyphs:
+			st.WriteGlyphs(ImageToGlyphs(showImg), 0, 0, true)
+		case st.brail:
+			st.WriteGlyphs(ImageToBraille(showImg), 0, 0, true)
+		default:
+			_ = st.ap.ShowScaledImage(showImg)
+		}
 	}
 	st.ap.EndSyncMode()
-	st.last = time.Now()
 }
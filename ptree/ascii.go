@@ -0,0 +1,142 @@
+package ptree
+
+import (
+	"math"
+
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// ASCIICell is one character cell of an ASCII rendering (Char 0 means empty).
+type ASCIICell struct {
+	Char  byte
+	Color tcolor.RGBColor
+}
+
+// leafChars are the characters used for leaves, picked from the cell position so redraws are stable.
+const leafChars = "&&&%&@&*"
+
+// Characters used for the flowers (and spring blossoms) and fruits.
+const (
+	blossomChar = 'o'
+	fruitChar   = 'O'
+)
+
+// DrawASCII renders the tree cbonsai style with characters picked by branch direction
+// (`/`, `\`, `|`, `_` and `~` for thick near horizontal ones) and leaves as `&` and similar.
+// Each cell covers 1x2 canvas pixels (like the half block rendering) so the grid has
+// [Canvas.Width] columns and half of [Canvas.Height] rows.
+func DrawASCII(c *Canvas) [][]ASCIICell {
+	cols, rows := c.Width, (c.Height+1)/2
+	grid := make([][]ASCIICell, rows)
+	for i := range grid {
+		grid[i] = make([]ASCIICell, cols)
+	}
+	set := func(x, y float64, ch byte, rgb tcolor.RGBColor) {
+		col, row := int(math.Floor(x)), int(math.Floor(y/2))
+		if col < 0 || col >= cols || row < 0 || row >= rows {
+			return
+		}
+		grid[row][col] = ASCIICell{Char: ch, Color: rgb}
+	}
+	c.prepareDraw(c.Width)
+	branches, grown := c.frameBranches()
+	for i, b := range grown {
+		if b == nil {
+			continue // not grown yet
+		}
+		rgb := c.branchColors[i]
+		steps := max(1, int(math.Ceil(2*b.Length)))
+		for s := 0; s <= steps; s++ {
+			t := float64(s) / float64(steps)
+			p := b.PointAt(t)
+			dirX, dirY := b.TangentAt(t)
+			halfWidth := (b.StartWidth + (b.EndWidth-b.StartWidth)*t) / 2
+			ch := asciiBranchChar(dirX, dirY, 2*halfWidth)
+			// Fill across the branch width, along the normal.
+			for o := -halfWidth; o <= halfWidth; o += 0.5 {
+				set(p.X-dirY*o, p.Y+dirX*o, ch, rgb)
+			}
+			set(p.X, p.Y, ch, rgb)
+		}
+	}
+	growth := c.leafGrowth()
+	if growth <= 0 {
+		return grid
+	}
+	for i := range c.Foliage {
+		if !c.attached(i) {
+			continue // falling or fallen
+		}
+		leaf := &c.Foliage[i]
+		b := branches[leaf.Branch]
+		pos := b.PointAt(leaf.T)
+		size := growth * c.leafScale * leaf.SizeVariation
+		if rgb, ok := c.blossom(i); ok {
+			set(pos.X, pos.Y, blossomChar, rgb)
+			continue
+		}
+		rgb, ok := c.seasonLeaf(i)
+		if !ok {
+			continue
+		}
+		// Cluster of leaf characters about a third of the size of the drawn leaf.
+		r := size * max(8, 4*b.EndWidth) / 3
+		for y := math.Floor(pos.Y - r); y <= pos.Y+r; y++ {
+			for x := math.Floor(pos.X - r); x <= pos.X+r; x++ {
+				if dx, dy := x+0.5-pos.X, y+0.5-pos.Y; dx*dx+dy*dy > r*r {
+					continue
+				}
+				set(x, y, asciiLeafChar(x, y), rgb)
+			}
+		}
+		set(pos.X, pos.Y, leafChars[0], rgb)
+	}
+	if c.Leaves && c.Fall != nil {
+		// A single character per falling or fallen leaf.
+		for _, leaves := range [...][]fallingLeaf{c.Fall.fallen, c.Fall.falling} {
+			for _, l := range leaves {
+				set(l.Pos.X, l.Pos.Y, asciiLeafChar(l.Pos.X, l.Pos.Y), l.Color)
+			}
+		}
+	}
+	if c.Fruit != nil && c.Season != Spring {
+		for i := range c.Fruits {
+			_, center, _ := c.fruitGeometry(i, branches, growth)
+			set(center.X, center.Y, fruitChar, c.Fruit.Color)
+		}
+	}
+	if c.Flowers && c.Season != Winter {
+		for i := range c.Blooms {
+			x, y, _ := c.flowerPosition(&c.Blooms[i], branches, growth)
+			set(x, y, blossomChar, c.Blooms[i].Color)
+		}
+	}
+	return grid
+}
+
+// asciiLeafChar returns the leaf character for the cell of canvas pixel x, y.
+func asciiLeafChar(x, y float64) byte {
+	return leafChars[(int(x)*31+int(y/2)*17)&(len(leafChars)-1)]
+}
+
+// asciiBranchChar returns the character for a branch going in the given direction (image
+// coordinates, Y down).
+func asciiBranchChar(dirX, dirY, width float64) byte {
+	a := math.Atan2(-dirY, dirX)
+	if a < 0 {
+		a += math.Pi // direction of travel doesn't matter
+	}
+	switch {
+	case a < math.Pi/8 || a >= 7*math.Pi/8:
+		if width >= 2 {
+			return '~'
+		}
+		return '_'
+	case a < 3*math.Pi/8:
+		return '/'
+	case a < 5*math.Pi/8:
+		return '|'
+	default:
+		return '\\'
+	}
+}
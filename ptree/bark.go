@@ -0,0 +1,116 @@
+package ptree
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// BarkStyle is the procedural texture of the branches at high resolution.
+type BarkStyle int
+
+const (
+	BarkFlat     BarkStyle = iota // No texture, a single color (default)
+	BarkSmooth                    // Fine grain with small horizontal lenticels, e.g cherry or beech
+	BarkFurrowed                  // Deep vertical fissures between ridges, e.g oak
+	BarkPlated                    // Cracked into irregular plates, e.g pine
+	BarkPeeling                   // Light patches where the bark peels off, e.g birch or plane
+)
+
+var barkNames = []string{"flat", "smooth", "furrowed", "plated", "peeling"}
+
+func (s BarkStyle) String() string {
+	if s < 0 || int(s) >= len(barkNames) {
+		return fmt.Sprintf("BarkStyle(%d)", int(s))
+	}
+	return barkNames[s]
+}
+
+// BarkNames returns the names of the bark styles (for [ParseBarkStyle]).
+func BarkNames() []string {
+	return slices.Clone(barkNames)
+}
+
+// ParseBarkStyle returns the bark style for the given name.
+func ParseBarkStyle(name string) (BarkStyle, error) {
+	for i, n := range barkNames {
+		if n == name {
+			return BarkStyle(i), nil
+		}
+	}
+	return BarkFlat, fmt.Errorf("unknown bark style %q, should be one of %v", name, barkNames)
+}
+
+// minBarkWidth is the branch width in pixels under which there is no room for a texture.
+const minBarkWidth = 6
+
+// barkTextured returns true if branch b gets a bark texture at that image width: not at low
+// resolution (see lowResLight) nor on thin branches.
+func (c *Canvas) barkTextured(b *Branch, imgWidth int) bool {
+	return c.Bark != BarkFlat && imgWidth >= lowResLight && b.StartWidth >= minBarkWidth
+}
+
+// noiseHash returns a pseudo random value in [0, 1) for the integer lattice point x, y.
+func noiseHash(x, y int, seed float64) float64 {
+	v := math.Sin(float64(x)*127.1+float64(y)*311.7+seed*74.7) * 43758.5453
+	return v - math.Floor(v)
+}
+
+// valueNoise returns smooth noise in [0, 1) interpolating random values at integer coordinates.
+func valueNoise(x, y, seed float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	// Smoothstep for continuous derivatives.
+	fx, fy = fx*fx*(3-2*fx), fy*fy*(3-2*fy)
+	ix, iy := int(x0), int(y0)
+	a := noiseHash(ix, iy, seed) + (noiseHash(ix+1, iy, seed)-noiseHash(ix, iy, seed))*fx
+	b := noiseHash(ix, iy+1, seed) + (noiseHash(ix+1, iy+1, seed)-noiseHash(ix, iy+1, seed))*fx
+	return a + (b-a)*fy
+}
+
+// value returns the bark texture at along (distance along the branch in units of its half
+// width, so the pattern scales with the width) and around (angle around the branch in
+// radians, -π/2 to π/2 from one visible edge to the other): -1 for the darkest fissures, 0 for
+// the base color and up to 1 for the lightest parts. seed varies the pattern per branch.
+func (s BarkStyle) value(along, around, seed float64) float64 {
+	switch s {
+	case BarkSmooth:
+		grain := valueNoise(along*2, around*6, seed) - 0.5
+		// Lenticels: short thin horizontal dashes.
+		row := along * 1.5
+		lenticel := 0.0
+		if f := row - math.Floor(row); f < 0.08 && valueNoise(math.Floor(row), around*3, seed+1) > 0.6 {
+			lenticel = -0.6
+		}
+		return 0.25*grain + lenticel
+	case BarkFurrowed:
+		// Ridges along the branch, wandering a bit, with narrow dark fissures between them.
+		ridge := around*4 + 1.2*valueNoise(along*0.4, around, seed)
+		f := math.Abs(math.Sin(math.Pi * ridge))
+		return 0.9*(math.Sqrt(f)-0.55) + 0.3*(valueNoise(along*3, around*10, seed+2)-0.5)
+	case BarkPlated:
+		// Rows of plates with staggered cracks, each plate a bit lighter or darker.
+		v := around*3 + 0.3*valueNoise(along*0.5, around*2, seed)
+		col := math.Floor(v)
+		u := along*0.6 + noiseHash(int(col), 0, seed)*2
+		row := math.Floor(u)
+		edge := min(v-col, 1-(v-col), 1.6*(u-row), 1.6*(1-(u-row))) // distance to the plate border
+		if edge < 0.08 {
+			return -0.9
+		}
+		return 0.35*(noiseHash(int(col), int(row), seed+3)-0.5) + 0.15*(valueNoise(along*4, around*12, seed)-0.5)
+	case BarkPeeling:
+		// Lighter patches, stretched around the branch, with a dark curled edge.
+		n := valueNoise(along*0.5, around*1.5, seed) + 0.3*valueNoise(along*2, around*4, seed+4)
+		switch {
+		case n > 0.85:
+			return 0.8
+		case n > 0.8:
+			return -0.6
+		default:
+			return 0.2 * (valueNoise(along*3, around*8, seed+5) - 0.5)
+		}
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,233 @@
+package ptree
+
+import (
+	"fmt"
+	"math"
+)
+
+// Algorithm selects the tree generation algorithm used by [Canvas.Generate].
+type Algorithm int
+
+const (
+	// Recursive is the default left/right/mid branching from [Branch.Add].
+	Recursive Algorithm = iota
+	// Colonize is the space colonization algorithm (see [Canvas.GenerateColonize]).
+	Colonize
+)
+
+var algorithmNames = []string{"branch", "colonize"}
+
+func (a Algorithm) String() string {
+	if a < 0 || int(a) >= len(algorithmNames) {
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+	return algorithmNames[a]
+}
+
+// ParseAlgorithm returns the algorithm for the given name (branch or colonize).
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for i, n := range algorithmNames {
+		if n == name {
+			return Algorithm(i), nil
+		}
+	}
+	return Recursive, fmt.Errorf("unknown algorithm %q, should be one of %v", name, algorithmNames)
+}
+
+// CrownShape is the envelope inside which attraction points are scattered for [Colonize].
+type CrownShape int
+
+const (
+	CrownEllipse CrownShape = iota
+	CrownCone
+	CrownDome
+)
+
+var crownShapeNames = []string{"ellipse", "cone", "dome"}
+
+func (s CrownShape) String() string {
+	if s < 0 || int(s) >= len(crownShapeNames) {
+		return fmt.Sprintf("CrownShape(%d)", int(s))
+	}
+	return crownShapeNames[s]
+}
+
+// ParseCrownShape returns the crown shape for the given name (ellipse, cone or dome).
+func ParseCrownShape(name string) (CrownShape, error) {
+	for i, n := range crownShapeNames {
+		if n == name {
+			return CrownShape(i), nil
+		}
+	}
+	return CrownEllipse, fmt.Errorf("unknown crown shape %q, should be one of %v", name, crownShapeNames)
+}
+
+// crown is the attraction envelope in canvas coordinates.
+type crown struct {
+	shape           CrownShape
+	cx, top, bottom float64
+	rx              float64
+}
+
+// Contains returns true if the point is inside the crown envelope.
+func (cr *crown) Contains(x, y float64) bool {
+	if y < cr.top || y > cr.bottom {
+		return false
+	}
+	h := cr.bottom - cr.top
+	dx := (x - cr.cx) / cr.rx
+	switch cr.shape {
+	case CrownCone:
+		// Apex at the top, widest at the bottom.
+		return math.Abs(dx) <= (y-cr.top)/h
+	case CrownDome:
+		// Upper half of an ellipse with a flat base.
+		dy := (cr.bottom - y) / h
+		return dx*dx+dy*dy <= 1
+	default: // CrownEllipse
+		dy := (y - (cr.top+cr.bottom)/2) / (h / 2)
+		return dx*dx+dy*dy <= 1
+	}
+}
+
+// colonizeNode is a growth node, the segment from its parent to it becomes a [Branch].
+type colonizeNode struct {
+	pos    Point
+	parent int     // index in the node list, -1 for the trunk end
+	dirX   float64 // accumulated direction toward attractors during one iteration
+	dirY   float64
+	count  int // number of attractors pulling on this node during one iteration
+}
+
+// Limits so a degenerate setup (e.g tiny kill distance) still terminates quickly.
+const (
+	maxColonizeIterations = 400
+	maxColonizeNodes      = 20000
+)
+
+// GenerateColonize generates the tree using the space colonization algorithm:
+// [Canvas.Attractors] points are scattered in the [Canvas.CrownShape] envelope above the trunk,
+// each iteration every point pulls its closest node which grows one step toward the average
+// direction, and points closer than [Canvas.KillDistancePct] to a node are removed.
+// Widths are then computed back from the tips (pipe model) and the result is stored
+// in [Canvas.Branches] like [Canvas.Generate] does.
+func (c *Canvas) GenerateColonize() {
+	c.Branches = c.Branches[:0]
+	trunk := c.Trunk(c.TrunkWidthPct, c.TrunkHeightPct)
+	w, h := float64(c.Width), float64(c.Height)
+	cr := crown{
+		shape:  c.CrownShape,
+		cx:     w / 2,
+		top:    0.04 * h,
+		bottom: min(h, trunk.End.Y+0.1*trunk.Length),
+		rx:     0.45 * w * c.Spread,
+	}
+	if cr.bottom-cr.top < 2 {
+		cr.top = 0
+	}
+	// Scatter attractors with rejection sampling in the bounding box.
+	numAttractors := max(1, c.Attractors)
+	attractors := make([]Point, 0, numAttractors)
+	for tries := 0; len(attractors) < numAttractors && tries < 100*numAttractors; tries++ {
+		x := cr.cx + (2*c.Rand.Float64()-1)*cr.rx
+		y := cr.top + c.Rand.Float64()*(cr.bottom-cr.top)
+		if cr.Contains(x, y) {
+			attractors = append(attractors, Point{X: x, Y: y})
+		}
+	}
+	killDist := max(1.0, w*c.KillDistancePct/100.0)
+	step := max(0.5, killDist/2)
+	nodes := []colonizeNode{{pos: trunk.End, parent: -1}}
+	for range maxColonizeIterations {
+		if len(attractors) == 0 || len(nodes) >= maxColonizeNodes {
+			break
+		}
+		// Each attractor pulls its closest node.
+		for _, a := range attractors {
+			best, bestD2 := -1, math.Inf(1)
+			for j := range nodes {
+				dx, dy := a.X-nodes[j].pos.X, a.Y-nodes[j].pos.Y
+				if d2 := dx*dx + dy*dy; d2 < bestD2 {
+					best, bestD2 = j, d2
+				}
+			}
+			d := math.Sqrt(bestD2)
+			if d > 0 {
+				n := &nodes[best]
+				n.dirX += (a.X - n.pos.X) / d
+				n.dirY += (a.Y - n.pos.Y) / d
+				n.count++
+			}
+		}
+		// Grow one step from each pulled node.
+		light := c.phototropism()
+		grew := false
+		numNodes := len(nodes)
+		for j := range numNodes {
+			n := &nodes[j]
+			if n.count == 0 {
+				continue
+			}
+			// Small random wiggle for a more natural look (and to break exact ties).
+			dx := n.dirX/float64(n.count) + (c.Rand.Float64()-0.5)*0.2
+			dy := n.dirY/float64(n.count) + (c.Rand.Float64()-0.5)*0.2
+			// Gravity pulls down (+Y in image coordinates) and light toward its direction.
+			dx += 0.3 * light.X
+			dy += 0.3 * (c.Gravity - light.Y)
+			n.dirX, n.dirY, n.count = 0, 0, 0
+			l := math.Hypot(dx, dy)
+			if l < 1e-6 {
+				continue
+			}
+			nodes = append(nodes, colonizeNode{
+				pos:    Point{X: n.pos.X + step*dx/l, Y: n.pos.Y + step*dy/l},
+				parent: j,
+			})
+			grew = true
+		}
+		if !grew {
+			break
+		}
+		// Remove attractors that have been reached.
+		kept := attractors[:0]
+		for _, a := range attractors {
+			reached := false
+			for j := numNodes; j < len(nodes) && !reached; j++ {
+				reached = math.Hypot(a.X-nodes[j].pos.X, a.Y-nodes[j].pos.Y) < killDist
+			}
+			if !reached {
+				kept = append(kept, a)
+			}
+		}
+		attractors = kept
+	}
+	// Convert nodes to branches: trunk is branch 0 (node 0 is its end), node j>0 is branch j.
+	c.Branches = append(c.Branches, trunk)
+	parents := []int{-1}
+	for j := 1; j < len(nodes); j++ {
+		p := nodes[nodes[j].parent].pos
+		dx, dy := nodes[j].pos.X-p.X, nodes[j].pos.Y-p.Y
+		b := &Branch{
+			Start:  p,
+			Angle:  math.Atan2(-dy, dx),
+			Length: math.Hypot(dx, dy) + 0.75, // slight overlap to avoid anti-aliasing seams
+			Rand:   c.Rand,
+			Spread: c.Spread,
+		}
+		b.SetEnd()
+		c.Branches = append(c.Branches, b)
+		parents = append(parents, nodes[j].parent)
+	}
+	c.assignPipeWidths(parents, trunk.StartWidth, colonizeExponent)
+	// Start each segment a bit back into its parent to hide the notches at bends.
+	for _, b := range c.Branches[1:] {
+		dirX, dirY := b.Direction()
+		back := min(b.StartWidth*0.3, b.Length/3)
+		b.Start.X -= dirX * back
+		b.Start.Y -= dirY * back
+		b.Length += back
+	}
+	if c.Curves {
+		c.curveSegments(parents)
+	}
+}
@@ -0,0 +1,68 @@
+package ptree
+
+import "math"
+
+// SetCurve turns the branch into a cubic Bezier curve leaving its start along the
+// (normalized) given direction, typically the parent's tangent so there is no visible
+// angle at the joint, and arriving at End along the branch own direction so children
+// can do the same.
+func (b *Branch) SetCurve(startDirX, startDirY float64) {
+	dirX, dirY := b.Direction()
+	third := b.Length / 3
+	b.Curved = true
+	b.Ctrl1 = Point{X: b.Start.X + startDirX*third, Y: b.Start.Y + startDirY*third}
+	b.Ctrl2 = Point{X: b.End.X - dirX*third, Y: b.End.Y - dirY*third}
+}
+
+// PointAt returns the point at parameter t (0 = Start, 1 = End) along the branch.
+func (b *Branch) PointAt(t float64) Point {
+	if !b.Curved {
+		dirX, dirY := b.Direction()
+		return Point{X: b.Start.X + dirX*b.Length*t, Y: b.Start.Y + dirY*b.Length*t}
+	}
+	u := 1 - t
+	w0, w1, w2, w3 := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return Point{
+		X: w0*b.Start.X + w1*b.Ctrl1.X + w2*b.Ctrl2.X + w3*b.End.X,
+		Y: w0*b.Start.Y + w1*b.Ctrl1.Y + w2*b.Ctrl2.Y + w3*b.End.Y,
+	}
+}
+
+// TangentAt returns the normalized direction of the branch at parameter t.
+func (b *Branch) TangentAt(t float64) (dirX, dirY float64) {
+	if !b.Curved {
+		return b.Direction()
+	}
+	u := 1 - t
+	w0, w1, w2 := 3*u*u, 6*u*t, 3*t*t
+	dx := w0*(b.Ctrl1.X-b.Start.X) + w1*(b.Ctrl2.X-b.Ctrl1.X) + w2*(b.End.X-b.Ctrl2.X)
+	dy := w0*(b.Ctrl1.Y-b.Start.Y) + w1*(b.Ctrl2.Y-b.Ctrl1.Y) + w2*(b.End.Y-b.Ctrl2.Y)
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return b.Direction()
+	}
+	return dx / l, dy / l
+}
+
+// curveSamples returns how many segments to use to approximate the curve.
+func (b *Branch) curveSamples() int {
+	if !b.Curved {
+		return 1
+	}
+	return max(4, min(32, int(b.Length/4)))
+}
+
+// curveSegments bends each segment to leave along its parent tangent (used for the
+// generators producing chains of segments: L-system and space colonization).
+func (c *Canvas) curveSegments(parents []int) {
+	for i, b := range c.Branches {
+		p := parents[i]
+		if p < 0 {
+			if !b.Curved { // keep the trunk lean if already set
+				b.SetCurve(b.Direction())
+			}
+			continue
+		}
+		b.SetCurve(c.Branches[p].TangentAt(1))
+	}
+}
@@ -0,0 +1,46 @@
+package ptree
+
+import (
+	"testing"
+
+	"fortio.org/rand"
+)
+
+func TestCurvesSameTree(t *testing.T) {
+	for _, algo := range []Algorithm{Recursive, Colonize} {
+		t.Run(algo.String(), func(t *testing.T) {
+			trees := make([]*Canvas, 2)
+			for i, curves := range []bool{false, true} {
+				c := &Canvas{
+					Width:           400,
+					Height:          300,
+					MaxDepth:        6,
+					Spread:          1,
+					TrunkWidthPct:   7,
+					TrunkHeightPct:  35,
+					Algorithm:       algo,
+					Attractors:      200,
+					KillDistancePct: 3,
+					Curves:          curves,
+					Rand:            rand.New(42),
+				}
+				if err := c.Generate(); err != nil {
+					t.Fatalf("Generate() unexpected error: %v", err)
+				}
+				trees[i] = c
+			}
+			straight, curved := trees[0].Branches, trees[1].Branches
+			if len(straight) != len(curved) {
+				t.Fatalf("got %d branches with -curves, want the same %d as without", len(curved), len(straight))
+			}
+			// Only the positions change (mid branches start on the curve, not the chord).
+			for i := range straight {
+				s, c := straight[i], curved[i]
+				if s.Angle != c.Angle || s.Length != c.Length || s.StartWidth != c.StartWidth || s.EndWidth != c.EndWidth {
+					t.Errorf("branch %d angle, length and widths %g %g %g %g with curves, want %g %g %g %g", i,
+						c.Angle, c.Length, c.StartWidth, c.EndWidth, s.Angle, s.Length, s.StartWidth, s.EndWidth)
+				}
+			}
+		})
+	}
+}
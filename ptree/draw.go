@@ -18,23 +18,113 @@ func DrawTree(img draw.Image, c *Canvas, useLines bool) {
 		rast = vector.NewRasterizer(img.Bounds().Dx(), img.Bounds().Dy())
 		rast.DrawOp = draw.Over
 	}
+	c.prepareDraw(img.Bounds().Dx())
+	branches, grown := c.frameBranches()
+	snow := c.Snow && c.Season == Winter
+	cover := c.snowCover()
 	// Draw branches
-	for _, b := range c.Branches {
-		rgb := getBranchColor(c, b)
+	for i, b := range grown {
+		if b == nil {
+			continue // not grown yet
+		}
+		rgb := c.branchColors[i]
 		if useLines {
-			drawBranchLine(img.(*image.NRGBA), b, rgb)
+			drawBranchLine(img.(*image.NRGBA), b, c.litBranch(b, rgb))
 		} else {
-			drawBranchPolygon(img.(*image.RGBA), b, rgb, rast)
+			drawBranchPolygon(img.(*image.RGBA), b, c.branchSource(i, b, rgb, img.Bounds().Dx()), rast)
+		}
+		if snow {
+			// Right away so the branches drawn later (overlapping this one) cover it.
+			drawSnow(img, b, parentOf(branches, b), cover, useLines)
 		}
 	}
 	// Draw leaves after branches
 	if c.Leaves {
-		drawLeaves(img, c, useLines)
+		drawLeaves(img, c, branches, useLines)
+	}
+	if c.Fruit != nil {
+		drawFruits(img, c, branches, useLines)
+	}
+	if c.Flowers {
+		drawFlowers(img, c, branches, useLines)
+	}
+	if c.Leaves && c.Fall != nil {
+		drawFalling(img, c, useLines)
+	}
+	if snow && c.Snowfall != nil {
+		drawSnowfall(img, c, useLines)
+	}
+}
+
+// frameBranches returns the branches at the current animation time (swayed by the wind if
+// any) and their grown part (nil when not grown yet) for the growth animation.
+func (c *Canvas) frameBranches() (branches, grown []*Branch) {
+	branches = c.Branches
+	if c.Wind != nil {
+		branches = c.swayedBranches()
+	}
+	grown = branches
+	if c.Growing {
+		grown = c.grownBranches(branches)
+	}
+	return branches, grown
+}
+
+// prepareDraw computes (once per generated tree and resolution) the random parts of the
+// rendering: branch colors and leaves, so animated redraws of the same tree are stable.
+func (c *Canvas) prepareDraw(imgWidth int) {
+	if len(c.branchColors) != len(c.Branches) {
+		c.branchColors = c.branchColors[:0]
+		for _, b := range c.Branches {
+			c.branchColors = append(c.branchColors, getBranchColor(c, b))
+		}
+		c.computeCrown()
+	}
+	if c.Leaves && c.foliageWidth != imgWidth {
+		c.generateFoliage(imgWidth)
+	}
+	if c.Flowers && c.flowerWidth != imgWidth {
+		c.generateFlowers(imgWidth) // after the leaves so they are the same with or without flowers
+	}
+	if c.Fruit != nil && c.fruitWidth != imgWidth {
+		c.generateFruits(imgWidth)
+	}
+	if c.Growing {
+		c.growthLevels()
+	}
+}
+
+// resetDrawCache is called when a new tree is generated.
+func (c *Canvas) resetDrawCache() {
+	c.branchColors = c.branchColors[:0]
+	c.Foliage = c.Foliage[:0]
+	c.foliageWidth = 0
+	c.Blooms = c.Blooms[:0]
+	c.flowerWidth = 0
+	c.Fruits = c.Fruits[:0]
+	c.fruitWidth = 0
+	c.levels = c.levels[:0]
+	c.shades = c.shades[:0]
+	if c.Fall != nil {
+		c.Fall.reset()
+	}
+	if c.Snowfall != nil {
+		c.Snowfall.reset()
 	}
 }
 
 func drawBranchLine(img *image.NRGBA, b *Branch, rgb tcolor.RGBColor) {
-	ansipixels.DrawAALine(img, b.Start.X, b.Start.Y, b.End.X, b.End.Y, toNRGBA(rgb))
+	if !b.Curved {
+		ansipixels.DrawAALine(img, b.Start.X, b.Start.Y, b.End.X, b.End.Y, toNRGBA(rgb))
+		return
+	}
+	n := b.curveSamples()
+	prev := b.Start
+	for i := 1; i <= n; i++ {
+		p := b.PointAt(float64(i) / float64(n))
+		ansipixels.DrawAALine(img, prev.X, prev.Y, p.X, p.Y, toNRGBA(rgb))
+		prev = p
+	}
 }
 
 func toNRGBA(rgb tcolor.RGBColor) color.NRGBA {
@@ -98,36 +188,32 @@ func getBranchColor(c *Canvas, b *Branch) tcolor.RGBColor {
 	return tcolor.RGBColor{R: r, G: g, B: blue}
 }
 
-// getLeafColor returns a green color for leaves with some variation.
+// getLeafColor returns a green (or species specific hue) color for leaves with some variation.
 func getLeafColor(c *Canvas) tcolor.RGBColor {
 	// Green with slight hue variation
-	hue := 0.33 + (c.Rand.Float64()-0.5)*0.1 // Around 120° (green) with variation
-	lightness := 0.5 + c.Rand.Float64()*0.2  // 0.5-0.7 range
-	chroma := 0.6 + c.Rand.Float64()*0.2     // 0.6-0.8 range
+	hue := c.species().LeafHue + (c.Rand.Float64()-0.5)*0.1 // Around 120° (green) with variation
+	lightness := 0.5 + c.Rand.Float64()*0.2                 // 0.5-0.7 range
+	chroma := 0.6 + c.Rand.Float64()*0.2                    // 0.6-0.8 range
+	hue -= math.Floor(hue)                                  // Wrap around for hues near 0 (red)
 	clr := tcolor.Oklchf(lightness, chroma, hue)
 	ct, data := clr.Decode()
 	return tcolor.ToRGB(ct, data)
 }
 
-// drawLeaves renders leaves at terminal and near-terminal branches.
-func drawLeaves(img draw.Image, c *Canvas, useLines bool) {
+// generateFoliage places leaves at terminal and near-terminal branches.
+func (c *Canvas) generateFoliage(imgWidth int) {
 	// Auto-detect resolution and adjust leaf parameters
 	// High-res (Kitty/PNG): bigger leaves, more of them
 	// Low-res (ANSI): smaller leaves, fewer of them
-	imgWidth := img.Bounds().Dx()
-	leafSizeMultiplier := c.LeafSize
+	leafSizeMultiplier := c.LeafSize * resolutionScale(imgWidth)
 	numLeavesBase := 3
 	numLeavesTerminal := 6
 
 	// If width < 200, we're in low-res ANSI mode
 	if imgWidth < 200 {
-		// Low-res: reduce leaf size significantly and use fewer leaves
-		leafSizeMultiplier *= 0.5
+		// Low-res: use fewer leaves
 		numLeavesBase = 1
 		numLeavesTerminal = 1
-	} else if imgWidth > 800 {
-		// High-res large images: increase leaf size
-		leafSizeMultiplier *= 2.0
 	}
 
 	// Allow manual override via LeafDensity
@@ -135,10 +221,13 @@ func drawLeaves(img draw.Image, c *Canvas, useLines bool) {
 		numLeavesBase = c.LeafDensity
 		numLeavesTerminal = c.LeafDensity + 2
 	}
+	c.Foliage = c.Foliage[:0]
+	c.foliageWidth = imgWidth
+	c.leafScale = leafSizeMultiplier
 
-	for _, b := range c.Branches {
+	for i, b := range c.Branches {
 		// Draw leaves on branches near the end (top 2 depth levels)
-		if b.Depth < c.MaxDepth-1 {
+		if b.Depth < c.MaxDepth-1 || b.Root {
 			continue
 		}
 		// More leaves at terminal branches, fewer at depth-1
@@ -153,60 +242,129 @@ func drawLeaves(img draw.Image, c *Canvas, useLines bool) {
 			if b.Depth == c.MaxDepth {
 				t = 0.5 + c.Rand.Float64()*0.5 // Even more toward end for terminal branches
 			}
-			dirX, dirY := b.Direction()
-			leafX := b.Start.X + dirX*b.Length*t
-			leafY := b.Start.Y + dirY*b.Length*t
+			pos := b.PointAt(t)
 			leafColor := getLeafColor(c)
 			// Random angle for leaf orientation
 			angle := c.Rand.Float64() * math.Pi * 2
-			if useLines {
-				drawLeafTriangle(img.(*image.NRGBA), leafX, leafY, angle, b.EndWidth, leafColor, leafSizeMultiplier, true)
-			} else {
-				drawLeafTriangle(img.(*image.RGBA), leafX, leafY, angle, b.EndWidth, leafColor, leafSizeMultiplier, false)
-			}
+			c.Foliage = append(c.Foliage, Leaf{
+				Branch:        i,
+				T:             t,
+				Angle:         angle,
+				Color:         leafColor,
+				SizeVariation: 0.8 + 0.4*math.Sin(pos.X+pos.Y), // deterministic ±20% variation based on position
+			})
+		}
+	}
+}
+
+// drawLeaves renders the foliage along the given (possibly swayed) branches.
+func drawLeaves(img draw.Image, c *Canvas, branches []*Branch, useLines bool) {
+	growth := c.leafGrowth()
+	if growth <= 0 {
+		return // still growing branches
+	}
+	for i := range c.Foliage {
+		if !c.attached(i) {
+			continue // falling or fallen
+		}
+		leaf := &c.Foliage[i]
+		b := branches[leaf.Branch]
+		pos := b.PointAt(leaf.T)
+		size := growth * c.leafScale * leaf.SizeVariation
+		if rgb, ok := c.blossom(i); ok {
+			drawFlower(img, pos.X, pos.Y, flowerRadius(b.EndWidth, size), leaf.Angle, rgb, useLines)
+			continue
 		}
+		rgb, ok := c.seasonLeaf(i)
+		if !ok {
+			continue
+		}
+		angle := leaf.Angle
+		if c.Wind != nil {
+			angle += c.Wind.flutter(c.Time, i)
+		}
+		drawLeaf(img, pos.X, pos.Y, angle, b.EndWidth, c.litLeaf(i, pos, rgb), size, c.LeafShape, useLines)
 	}
 }
 
-// drawLeafTriangle renders a triangular leaf at the given position.
-func drawLeafTriangle(
-	img draw.Image, x, y, angle, branchWidth float64, rgb tcolor.RGBColor, sizeMultiplier float64, useLines bool,
+// drawLeaf renders a leaf of the given shape at the given position.
+// sizeMultiplier includes the per leaf size variation.
+func drawLeaf(
+	img draw.Image, x, y, angle, branchWidth float64, rgb tcolor.RGBColor, sizeMultiplier float64,
+	shape LeafShape, useLines bool,
 ) {
+	for _, points := range leafPolygons(x, y, angle, branchWidth, sizeMultiplier, shape) {
+		if !useLines {
+			// Polygon mode: fill the leaf
+			fillPolygon(img.(*image.RGBA), points, rgb)
+			continue
+		}
+		nimg := img.(*image.NRGBA)
+		if len(points) == 6 {
+			// Line mode: draw just one edge of the triangles (tip to base)
+			ansipixels.DrawAALine(nimg, points[0], points[1], points[2], points[3], toNRGBA(rgb))
+			continue
+		}
+		// and the outline of the other shapes.
+		for k := 0; k < len(points); k += 2 {
+			j := (k + 2) % len(points)
+			ansipixels.DrawAALine(nimg, points[k], points[k+1], points[j], points[j+1], toNRGBA(rgb))
+		}
+	}
+}
+
+// leafTriangle returns the vertices of a leaf: its tip and the 2 points of its base.
+func leafTriangle(x, y, angle, branchWidth, sizeMultiplier float64, shape LeafShape) (tip, base1, base2 Point) {
 	// Leaf size proportional to branch width but larger
 	baseSize := branchWidth * 4
 	if baseSize < 8 {
 		baseSize = 8 // Minimum visible size
 	}
-	baseSize *= sizeMultiplier
-	// Add some size variation (±20%)
-	sizeVariation := 0.8 + 0.4*math.Sin(x+y) // deterministic variation based on position
-	leafSize := baseSize * sizeVariation
+	leafSize := baseSize * sizeMultiplier
 
 	// Triangle vertices: pointing in random direction
 	// Tip of the leaf
-	tipX := x + math.Cos(angle)*leafSize
-	tipY := y + math.Sin(angle)*leafSize
+	tip = Point{X: x + math.Cos(angle)*leafSize, Y: y + math.Sin(angle)*leafSize}
 	// Base of the leaf (two points)
 	baseAngle1 := angle + math.Pi*0.75
 	baseAngle2 := angle - math.Pi*0.75
 	baseRadius := leafSize * 0.4
-	base1X := x + math.Cos(baseAngle1)*baseRadius
-	base1Y := y + math.Sin(baseAngle1)*baseRadius
-	base2X := x + math.Cos(baseAngle2)*baseRadius
-	base2Y := y + math.Sin(baseAngle2)*baseRadius
+	if shape == LeafNeedle {
+		// Longer and much thinner
+		tip = Point{X: x + math.Cos(angle)*leafSize*1.4, Y: y + math.Sin(angle)*leafSize*1.4}
+		baseRadius = leafSize * 0.12
+	}
+	base1 = Point{X: x + math.Cos(baseAngle1)*baseRadius, Y: y + math.Sin(baseAngle1)*baseRadius}
+	base2 = Point{X: x + math.Cos(baseAngle2)*baseRadius, Y: y + math.Sin(baseAngle2)*baseRadius}
+	return tip, base1, base2
+}
 
-	if useLines {
-		// Line mode: draw just one edge of the triangle
-		ansipixels.DrawAALine(img.(*image.NRGBA), tipX, tipY, base1X, base1Y, toNRGBA(rgb))
-	} else {
-		// Polygon mode: fill the triangle
-		fillTriangle(img.(*image.RGBA), tipX, tipY, base1X, base1Y, base2X, base2Y, rgb)
+// fillPolygon fills a polygon using vector rasterizer for smooth anti-aliased rendering.
+func fillPolygon(img *image.RGBA, points []float64, rgb tcolor.RGBColor) {
+	fillPolygonSrc(img, points, image.NewUniform(toRGBA(rgb)))
+}
+
+// fillPolygonSrc fills a polygon with the src image (in img coordinates).
+func fillPolygonSrc(img *image.RGBA, points []float64, src image.Image) {
+	x0Int, y0Int, x1Int, y1Int, offscreen := calcBoundingBox(points, img.Bounds())
+	if offscreen {
+		return
+	}
+	rast := vector.NewRasterizer(x1Int-x0Int, y1Int-y0Int)
+	rast.DrawOp = draw.Over
+	// Translate coordinates to local space
+	dx, dy := float32(x0Int), float32(y0Int)
+	rast.MoveTo(float32(points[0])-dx, float32(points[1])-dy)
+	for i := 2; i < len(points); i += 2 {
+		rast.LineTo(float32(points[i])-dx, float32(points[i+1])-dy)
 	}
+	rast.ClosePath()
+	subImg := img.SubImage(image.Rect(x0Int, y0Int, x1Int, y1Int)).(*image.RGBA)
+	rast.Draw(subImg, subImg.Bounds(), src, subImg.Bounds().Min)
 }
 
-// fillTriangle fills a triangle using vector rasterizer for smooth anti-aliased rendering.
-func fillTriangle(img *image.RGBA, x1, y1, x2, y2, x3, y3 float64, rgb tcolor.RGBColor) {
-	points := []float64{x1, y1, x2, y2, x3, y3}
+func drawBranchPolygon(img *image.RGBA, b *Branch, src image.Image, rast *vector.Rasterizer) {
+	points := b.outline()
 	x0Int, y0Int, x1Int, y1Int, offscreen := calcBoundingBox(points, img.Bounds())
 	if offscreen {
 		return
@@ -214,28 +372,33 @@ func fillTriangle(img *image.RGBA, x1, y1, x2, y2, x3, y3 float64, rgb tcolor.RG
 	wInt := x1Int - x0Int
 	hInt := y1Int - y0Int
 
-	// Create rasterizer for this triangle
-	rast := vector.NewRasterizer(wInt, hInt)
-	rast.DrawOp = draw.Over
-
+	// Reset rasterizer to tight bounding box
+	rast.Reset(wInt, hInt)
 	// Translate coordinates to local space
 	dx, dy := float32(x0Int), float32(y0Int)
-	rast.MoveTo(float32(x1)-dx, float32(y1)-dy)
-	rast.LineTo(float32(x2)-dx, float32(y2)-dy)
-	rast.LineTo(float32(x3)-dx, float32(y3)-dy)
+	rast.MoveTo(float32(points[0])-dx, float32(points[1])-dy)
+	for i := 2; i < len(points); i += 2 {
+		rast.LineTo(float32(points[i])-dx, float32(points[i+1])-dy)
+	}
 	rast.ClosePath()
 
 	// Rasterize to the bounding box region
 	subImg := img.SubImage(image.Rect(x0Int, y0Int, x1Int, y1Int)).(*image.RGBA)
-	rast.Draw(subImg, subImg.Bounds(), image.NewUniform(toRGBA(rgb)), image.Point{})
-}
-
-func drawBranchPolygon(img *image.RGBA, b *Branch, rgb tcolor.RGBColor, rast *vector.Rasterizer) {
-	perpX, perpY := b.Perpendicular()
-	if perpX == 0 && perpY == 0 {
+	if s, ok := src.(*branchShade); ok {
+		s.draw(subImg, rast)
 		return
 	}
+	rast.Draw(subImg, subImg.Bounds(), src, subImg.Bounds().Min)
+}
 
+// outline returns the (x, y) vertices of the tapered shape of the branch: a trapezoid for
+// straight branches, for curved ones the Bezier is sampled and offset on both sides by the
+// interpolated half width along the local normal.
+func (b *Branch) outline() []float64 {
+	if b.Curved {
+		return b.curveOutline()
+	}
+	perpX, perpY := b.Perpendicular()
 	startHalfWidth := b.StartWidth / 2
 	endHalfWidth := b.EndWidth / 2
 
@@ -252,26 +415,27 @@ func drawBranchPolygon(img *image.RGBA, b *Branch, rgb tcolor.RGBColor, rast *ve
 
 	e1x, e1y := b.End.X+perpX*endHalfWidth, b.End.Y+perpY*endHalfWidth
 	e2x, e2y := b.End.X-perpX*endHalfWidth, b.End.Y-perpY*endHalfWidth
+	return []float64{s1x, s1y, e1x, e1y, e2x, e2y, s2x, s2y}
+}
 
-	points := []float64{s1x, s1y, s2x, s2y, e1x, e1y, e2x, e2y}
-	x0Int, y0Int, x1Int, y1Int, offscreen := calcBoundingBox(points, img.Bounds())
-	if offscreen {
-		return
+func (b *Branch) curveOutline() []float64 {
+	n := b.curveSamples()
+	// Left side forward in the first half, right side in the second half (reversed when drawing).
+	points := make([]float64, 4*(n+1))
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		p := b.PointAt(t)
+		dirX, dirY := b.TangentAt(t)
+		perpX, perpY := -dirY, dirX
+		if i == 0 && b.Depth == 0 {
+			perpX, perpY = 1, 0 // Flat bottom for trunk
+		}
+		halfWidth := (b.StartWidth + (b.EndWidth-b.StartWidth)*t) / 2
+		points[2*i] = p.X + perpX*halfWidth
+		points[2*i+1] = p.Y + perpY*halfWidth
+		j := 2 * (2*n + 1 - i)
+		points[j] = p.X - perpX*halfWidth
+		points[j+1] = p.Y - perpY*halfWidth
 	}
-	wInt := x1Int - x0Int
-	hInt := y1Int - y0Int
-
-	// Reset rasterizer to tight bounding box
-	rast.Reset(wInt, hInt)
-	// Translate coordinates to local space
-	dx, dy := float32(x0Int), float32(y0Int)
-	rast.MoveTo(float32(s1x)-dx, float32(s1y)-dy)
-	rast.LineTo(float32(e1x)-dx, float32(e1y)-dy)
-	rast.LineTo(float32(e2x)-dx, float32(e2y)-dy)
-	rast.LineTo(float32(s2x)-dx, float32(s2y)-dy)
-	rast.ClosePath()
-
-	// Rasterize to the bounding box region
-	subImg := img.SubImage(image.Rect(x0Int, y0Int, x1Int, y1Int)).(*image.RGBA)
-	rast.Draw(subImg, subImg.Bounds(), image.NewUniform(toRGBA(rgb)), image.Point{})
+	return points
 }
@@ -0,0 +1,231 @@
+package ptree
+
+import (
+	"image/draw"
+	"math"
+
+	"fortio.org/rand"
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// LeafFall makes leaves detach from the tree over time, drift down fluttering (and pushed by
+// the [Wind] if any) and pile up at the bottom of the image, or on the [Canvas.Pot] rim (the
+// others falling out of view). It is advanced by [Canvas.StepFalling].
+type LeafFall struct {
+	Rate     float64 // Leaves detaching per second
+	Max      int     // Maximum number of leaves in the air (and 4 times that on the ground)
+	rnd      rand.Rand
+	time     float64 // Seconds since the start of the fall (for the flutter)
+	pending  float64 // Fractional leaves to detach
+	detached []bool  // Per [Canvas.Foliage] leaf
+	season   Season  // Of the detached leaves (new ones grow for the next season)
+	falling  []fallingLeaf
+	fallen   []fallingLeaf
+	pile     []float64 // Height of the pile per image column
+	first    int       // First and last columns of the pile (see [Canvas.groundColumns])
+	last     int
+}
+
+type fallingLeaf struct {
+	Pos         Point
+	Angle       float64
+	Size        float64 // Leaf size multiplier (see [leafPolygons])
+	BranchWidth float64 // Width of the branch it came from (see [leafPolygons])
+	Color       tcolor.RGBColor
+	Phase       float64
+	Speed       float64 // Fall speed multiplier (around 1)
+}
+
+// Rim is the horizontal extent, in pixels, of the top of a pot standing right below the image.
+type Rim struct {
+	Left, Right float64
+}
+
+// groundColumns returns the first and last image columns where the falling leaves and snow
+// pile up: the ones above the [Canvas.Pot] rim if set, else all of them.
+func (c *Canvas) groundColumns() (first, last int) {
+	if c.Pot == nil {
+		return 0, c.Width - 1
+	}
+	return max(0, int(c.Pot.Left)), min(c.Width-1, int(math.Ceil(c.Pot.Right))-1)
+}
+
+// NewLeafFall creates a leaf fall of rate leaves per second with at most maxFalling in the air.
+// The leaves picked and their motion are random, fully determined by rnd.
+func NewLeafFall(rate float64, maxFalling int, rnd rand.Rand) *LeafFall {
+	return &LeafFall{Rate: rate, Max: maxFalling, rnd: rnd}
+}
+
+// reset removes all the falling and fallen leaves (for a new tree or resolution).
+func (f *LeafFall) reset() {
+	f.pending = 0
+	f.detached = f.detached[:0]
+	f.falling = f.falling[:0]
+	f.fallen = f.fallen[:0]
+	f.pile = f.pile[:0]
+}
+
+// attached returns true if leaf i is still on the tree.
+func (c *Canvas) attached(i int) bool {
+	return c.Fall == nil || i >= len(c.Fall.detached) || !c.Fall.detached[i]
+}
+
+// StepFalling advances the leaf fall and snowfall (if [Canvas.Fall] and [Canvas.Snowfall] are
+// set) by dt seconds.
+func (c *Canvas) StepFalling(dt float64) {
+	if dt <= 0 {
+		return
+	}
+	if c.Fall != nil {
+		c.stepLeaves(dt)
+	}
+	if c.Snowfall != nil {
+		c.stepSnow(dt)
+	}
+}
+
+// stepLeaves advances the leaf fall: leaves detach (once the tree is fully grown), the ones in
+// the air move down and land.
+func (c *Canvas) stepLeaves(dt float64) {
+	f := c.Fall
+	f.time += dt
+	if f.season != c.Season {
+		f.season = c.Season
+		f.detached = f.detached[:0] // new foliage
+	}
+	if len(f.detached) != len(c.Foliage) {
+		f.detached = append(f.detached[:0], make([]bool, len(c.Foliage))...)
+	}
+	if len(f.pile) != c.Width {
+		f.pile = append(f.pile[:0], make([]float64, c.Width)...)
+	}
+	f.first, f.last = c.groundColumns()
+	height := float64(c.Height)
+	// Relative to the image height so the fall takes about the same time at all resolutions.
+	speed := 0.08 * float64(c.Height)
+	push := 0.0
+	if c.Wind != nil {
+		push = 0.1 * float64(c.Height) * c.Wind.Force(c.Time)
+	}
+	kept := f.falling[:0]
+	for _, l := range f.falling {
+		// Swinging from side to side while slowing down at the ends of the swing.
+		swing := math.Sin(1.5*f.time + l.Phase)
+		l.Pos.X += (push + 0.5*speed*swing) * dt
+		l.Pos.Y += speed * l.Speed * (0.6 + 0.4*math.Abs(swing)) * dt
+		l.Angle += 2 * math.Cos(1.5*f.time+l.Phase) * dt
+		if f.column(l.Pos.X) < 0 {
+			if l.Pos.Y < height+l.extent() {
+				kept = append(kept, l) // falling past the pot or out of the image
+			}
+			continue
+		}
+		if l.Pos.Y >= f.ground(l.Pos.X, height) {
+			f.land(l, height)
+			continue
+		}
+		kept = append(kept, l)
+	}
+	f.falling = kept
+	if c.Growing || c.leafGrowth() < 1 {
+		return
+	}
+	f.pending += f.Rate * dt
+	branches, _ := c.frameBranches()
+	for ; f.pending >= 1; f.pending-- {
+		if len(f.falling) >= f.Max || len(c.Foliage) == 0 {
+			f.pending = 0
+			break
+		}
+		f.detach(c, branches)
+	}
+}
+
+// detach picks a random leaf still on the tree and makes it fall.
+func (f *LeafFall) detach(c *Canvas, branches []*Branch) {
+	for range 8 { // a few tries to find one still there
+		i := f.rnd.IntN(len(c.Foliage))
+		if f.detached[i] {
+			continue
+		}
+		rgb, ok := c.seasonLeaf(i)
+		if !ok {
+			continue
+		}
+		if _, blossom := c.blossom(i); blossom {
+			continue
+		}
+		leaf := &c.Foliage[i]
+		b := branches[leaf.Branch]
+		f.detached[i] = true
+		f.falling = append(f.falling, fallingLeaf{
+			Pos:         b.PointAt(leaf.T),
+			Angle:       leaf.Angle,
+			Size:        c.leafScale * leaf.SizeVariation,
+			BranchWidth: b.EndWidth,
+			Color:       rgb,
+			Phase:       2 * math.Pi * f.rnd.Float64(),
+			Speed:       0.7 + 0.6*f.rnd.Float64(),
+		})
+		return
+	}
+}
+
+// extent returns the size of the leaf in pixels (see [leafPolygons]).
+func (l *fallingLeaf) extent() float64 {
+	return max(8, 4*l.BranchWidth) * l.Size
+}
+
+// column returns the pile column for x, -1 when outside of the pile (or the image).
+func (f *LeafFall) column(x float64) int {
+	i := int(x)
+	if x < 0 || i < f.first || i > f.last || i >= len(f.pile) {
+		return -1
+	}
+	return i
+}
+
+// ground returns the height at which a leaf at x (above the pile) lands: the top of the pile.
+func (f *LeafFall) ground(x, height float64) float64 {
+	return height - 1 - f.pile[f.column(x)]
+}
+
+// land adds leaf l to the pile (lying flat), removing the oldest fallen leaves beyond the limit:
+// the pile is then rebuilt from the remaining ones, settling down.
+func (f *LeafFall) land(l fallingLeaf, height float64) {
+	l.Angle = math.Pi * math.Round(l.Angle/math.Pi) // on either side
+	f.fallen = append(f.fallen, l)
+	extra := len(f.fallen) - 4*f.Max
+	if extra <= 0 {
+		f.heap(&f.fallen[len(f.fallen)-1], height)
+		return
+	}
+	f.fallen = append(f.fallen[:0], f.fallen[extra:]...)
+	clear(f.pile)
+	for i := range f.fallen {
+		f.heap(&f.fallen[i], height)
+	}
+}
+
+// heap puts fallen leaf l on top of the pile and raises the pile under it.
+func (f *LeafFall) heap(l *fallingLeaf, height float64) {
+	if f.column(l.Pos.X) < 0 {
+		return // the pile moved (new -pot size)
+	}
+	l.Pos.Y = f.ground(l.Pos.X, height)
+	size := l.extent()
+	for dx := -size / 2; dx <= size/2; dx++ {
+		if i := f.column(l.Pos.X + dx); i >= 0 {
+			f.pile[i] = min(height/4, f.pile[i]+0.25*size*(1-math.Abs(dx)/size))
+		}
+	}
+}
+
+// drawFalling renders the fallen leaves then the ones in the air.
+func drawFalling(img draw.Image, c *Canvas, useLines bool) {
+	for _, leaves := range [...][]fallingLeaf{c.Fall.fallen, c.Fall.falling} {
+		for _, l := range leaves {
+			drawLeaf(img, l.Pos.X, l.Pos.Y, l.Angle, l.BranchWidth, l.Color, l.Size, c.LeafShape, useLines)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ptree
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"fortio.org/terminal/ansipixels"
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// Flower is a five petal blossom (sakura/plum style) clustered near the tip of a terminal branch.
+type Flower struct {
+	Branch int     // Index in [Canvas.Branches]
+	T      float64 // Position along the branch (0 = start, 1 = end)
+	Offset Point   // From the branch point, in units of the flower radius
+	Angle  float64 // Orientation of the first petal in radians
+	Color  tcolor.RGBColor
+	Size   float64 // Size multiplier (around 1)
+}
+
+// DefaultFlowerColor is the sakura pink used when [Canvas.FlowerColor] isn't set.
+var DefaultFlowerColor = tcolor.RGBColor{R: 255, G: 170, B: 195}
+
+// flowerColor returns the flowers (and spring blossoms) color.
+func (c *Canvas) flowerColor() tcolor.RGBColor {
+	if c.FlowerColor == (tcolor.RGBColor{}) {
+		return DefaultFlowerColor
+	}
+	return c.FlowerColor
+}
+
+// flowerCenterColor is the color of the stamens in the middle of the flowers.
+var flowerCenterColor = tcolor.RGBColor{R: 250, G: 205, B: 90}
+
+// resolutionScale is the leaves (and flowers) size multiplier for the image resolution: smaller
+// for the low resolution terminal rendering, bigger for large high resolution images.
+func resolutionScale(imgWidth int) float64 {
+	switch {
+	case imgWidth < 200:
+		return 0.5
+	case imgWidth > 800:
+		return 2.0
+	default:
+		return 1.0
+	}
+}
+
+// generateFlowers places clusters of [Canvas.FlowerDensity] flowers near the end of the
+// terminal branches (the ones without children).
+func (c *Canvas) generateFlowers(imgWidth int) {
+	c.Blooms = c.Blooms[:0]
+	c.flowerWidth = imgWidth
+	c.flowerScale = c.FlowerSize * resolutionScale(imgWidth)
+	density := c.FlowerDensity
+	if density <= 0 {
+		density = 2
+		if imgWidth < 200 {
+			density = 1
+		}
+	}
+	base := c.flowerColor()
+	parent := make([]bool, len(c.Branches))
+	for _, b := range c.Branches {
+		if b.Parent >= 0 {
+			parent[b.Parent] = true
+		}
+	}
+	for i, b := range c.Branches {
+		if parent[i] || b.Root {
+			continue
+		}
+		for range density {
+			a := c.Rand.Float64() * 2 * math.Pi
+			d := c.Rand.Float64()
+			c.Blooms = append(c.Blooms, Flower{
+				Branch: i,
+				T:      0.6 + 0.4*c.Rand.Float64(),
+				Offset: Point{X: d * math.Cos(a), Y: d * math.Sin(a)},
+				Angle:  c.Rand.Float64() * 2 * math.Pi / 5,
+				Color:  lerpColor(base, tcolor.RGBColor{R: 255, G: 255, B: 255}, 0.4*c.Rand.Float64()),
+				Size:   0.8 + 0.4*c.Rand.Float64(),
+			})
+		}
+	}
+}
+
+// flowerRadius returns the radius of a flower on a branch of the given end width, the same
+// size as the (default) leaves (see [leafTriangle]).
+func flowerRadius(branchWidth, sizeMultiplier float64) float64 {
+	return 0.35 * max(8, branchWidth*4) * sizeMultiplier
+}
+
+// circle returns the points of a polygon approximating a circle (with more sides for larger ones).
+func circle(x, y, r float64) []float64 {
+	n := min(48, max(10, int(2*r)))
+	points := make([]float64, 0, 2*n)
+	for k := range n {
+		a := 2 * math.Pi * float64(k) / float64(n)
+		points = append(points, x+r*math.Cos(a), y+r*math.Sin(a))
+	}
+	return points
+}
+
+// flowerPetals returns the 5 petals (ellipses around the center) of a flower of radius r.
+func flowerPetals(x, y, r, angle float64) [5][]float64 {
+	const n = 8 // points per petal
+	var petals [5][]float64
+	for k := range petals {
+		a := angle + float64(k)*2*math.Pi/5
+		ca, sa := math.Cos(a), math.Sin(a)
+		cx, cy := x+0.55*r*ca, y+0.55*r*sa
+		points := make([]float64, 0, 2*n)
+		for j := range n {
+			t := 2 * math.Pi * float64(j) / n
+			u, v := 0.45*r*math.Cos(t), 0.32*r*math.Sin(t) // along, across the petal
+			points = append(points, cx+u*ca-v*sa, cy+u*sa+v*ca)
+		}
+		petals[k] = points
+	}
+	return petals
+}
+
+// drawFlower renders a five petal flower with its center or, in lines mode, its 5 petals as
+// lines from the center.
+func drawFlower(img draw.Image, x, y, r, angle float64, rgb tcolor.RGBColor, useLines bool) {
+	if useLines {
+		nimg := img.(*image.NRGBA)
+		for k := range 5 {
+			a := angle + float64(k)*2*math.Pi/5
+			ansipixels.DrawAALine(nimg, x, y, x+r*math.Cos(a), y+r*math.Sin(a), toNRGBA(rgb))
+		}
+		return
+	}
+	rimg := img.(*image.RGBA)
+	for _, petal := range flowerPetals(x, y, r, angle) {
+		fillPolygon(rimg, petal, rgb)
+	}
+	fillPolygon(rimg, circle(x, y, 0.22*r), flowerCenterColor)
+}
+
+// flowerPosition returns where flower f is drawn along the (possibly swayed) branches, and its radius.
+func (c *Canvas) flowerPosition(f *Flower, branches []*Branch, growth float64) (x, y, r float64) {
+	b := branches[f.Branch]
+	r = growth * flowerRadius(b.EndWidth, c.flowerScale*f.Size)
+	p := b.PointAt(f.T)
+	return p.X + f.Offset.X*r, p.Y + f.Offset.Y*r, r
+}
+
+// drawFlowers renders the flowers (budding with the leaves when growing), none in winter.
+func drawFlowers(img draw.Image, c *Canvas, branches []*Branch, useLines bool) {
+	growth := c.leafGrowth()
+	if growth <= 0 || c.Season == Winter {
+		return
+	}
+	for i := range c.Blooms {
+		f := &c.Blooms[i]
+		x, y, r := c.flowerPosition(f, branches, growth)
+		angle := f.Angle
+		if c.Wind != nil {
+			angle += c.Wind.flutter(c.Time, i)
+		}
+		drawFlower(img, x, y, r, angle, f.Color, useLines)
+	}
+}
@@ -0,0 +1,32 @@
+package ptree
+
+import "math"
+
+// bend rotates the angle toward the ground (gravity) and toward the light, amount scales
+// both effects (typically with depth and length so outer and longer branches bend more).
+// light is in the same convention as [Branch.Angle] (X right, Y up) and its length is the
+// phototropism strength.
+func bend(angle, gravity float64, light Point, amount float64) float64 {
+	if gravity != 0 {
+		// Rotating toward -π/2: sin(-π/2-angle) = -cos(angle).
+		angle -= amount * gravity * math.Cos(angle)
+	}
+	if light.X != 0 || light.Y != 0 {
+		strength := math.Hypot(light.X, light.Y)
+		angle += amount * strength * math.Sin(math.Atan2(light.Y, light.X)-angle)
+	}
+	return angle
+}
+
+// LightFromAngle returns the [Canvas.LightDirection] for a sun at the given angle in degrees
+// (90 is straight above, 0 is to the right).
+func LightFromAngle(degrees float64) Point {
+	rad := degrees * math.Pi / 180
+	return Point{X: math.Cos(rad), Y: math.Sin(rad)}
+}
+
+// phototropism returns the light for [bend]: the [Canvas.LightDirection] with the
+// [Canvas.Phototropism] strength as length.
+func (c *Canvas) phototropism() Point {
+	return Point{X: c.Phototropism * c.LightDirection.X, Y: c.Phototropism * c.LightDirection.Y}
+}
@@ -0,0 +1,178 @@
+package ptree
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"fortio.org/terminal/ansipixels"
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// FruitKind is a named fruit color and size preset.
+type FruitKind struct {
+	Name  string
+	Color tcolor.RGBColor
+	Size  float64 // Radius multiplier
+}
+
+// FruitKinds is the registry of fruit presets (in listing order).
+var FruitKinds = []*FruitKind{
+	{Name: "apple", Color: tcolor.RGBColor{R: 200, G: 30, B: 35}, Size: 1.0},
+	{Name: "orange", Color: tcolor.RGBColor{R: 250, G: 140, B: 20}, Size: 1.0},
+	{Name: "persimmon", Color: tcolor.RGBColor{R: 235, G: 95, B: 20}, Size: 0.9},
+	{Name: "lemon", Color: tcolor.RGBColor{R: 245, G: 220, B: 50}, Size: 0.8},
+	{Name: "plum", Color: tcolor.RGBColor{R: 110, G: 40, B: 110}, Size: 0.7},
+	{Name: "cherry", Color: tcolor.RGBColor{R: 150, G: 10, B: 30}, Size: 0.5},
+}
+
+// FruitNames returns the names of the fruit presets.
+func FruitNames() []string {
+	names := make([]string, 0, len(FruitKinds))
+	for _, f := range FruitKinds {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// FindFruit returns the fruit preset with the given name.
+func FindFruit(name string) (*FruitKind, error) {
+	for _, f := range FruitKinds {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown fruit %q, should be one of %v", name, FruitNames())
+}
+
+// Fruit hangs below a point of a (terminal or near terminal) branch.
+type Fruit struct {
+	Branch int     // Index in [Canvas.Branches]
+	T      float64 // Position of the stem along the branch (0 = start, 1 = end)
+	Tilt   float64 // Angle of the stem from the vertical in radians
+	Size   float64 // Size multiplier (around 1)
+}
+
+var stemColor = tcolor.RGBColor{R: 70, G: 50, B: 25}
+
+// fruitBaseRadius is the radius in pixels of a fruit of size 1 (before the resolution scaling).
+const fruitBaseRadius = 6
+
+// generateFruits hangs fruits on a [Canvas.FruitDensity] fraction of the terminal and
+// near terminal branches.
+func (c *Canvas) generateFruits(imgWidth int) {
+	c.Fruits = c.Fruits[:0]
+	c.fruitWidth = imgWidth
+	c.fruitScale = fruitBaseRadius * c.Fruit.Size * resolutionScale(imgWidth)
+	for i, b := range c.Branches {
+		if b.Depth < c.MaxDepth-1 || b.Root {
+			continue
+		}
+		if c.Rand.Float64() >= c.FruitDensity {
+			continue
+		}
+		c.Fruits = append(c.Fruits, Fruit{
+			Branch: i,
+			T:      0.4 + 0.6*c.Rand.Float64(),
+			Tilt:   (c.Rand.Float64() - 0.5) * 0.4,
+			Size:   0.85 + 0.3*c.Rand.Float64(),
+		})
+	}
+}
+
+// fruitGeometry returns where the stem of fruit i starts (on the possibly swayed branch) and
+// the center and radius of the fruit, hanging below it: gravity pulls it down whatever the
+// branch direction, the wind swings it.
+func (c *Canvas) fruitGeometry(i int, branches []*Branch, growth float64) (stem, center Point, r float64) {
+	f := &c.Fruits[i]
+	stem = branches[f.Branch].PointAt(f.T)
+	r = growth * c.fruitScale * f.Size
+	angle := f.Tilt
+	if c.Wind != nil {
+		angle -= 0.5 * c.Wind.flutter(c.Time, i)
+	}
+	// Short stem then the fruit body.
+	d := r * 1.4
+	center = Point{X: stem.X + d*math.Sin(angle), Y: stem.Y + d*math.Cos(angle)}
+	return stem, center, r
+}
+
+// radialShade is a sphere like shading: a highlight (see [Canvas.fruitHighlight]) fading to
+// the color and then to a darker rim.
+type radialShade struct {
+	cx, cy, r               float64 // highlight center and fade distance
+	highlight, base, shadow color.RGBA
+}
+
+// fruitHighlight returns the offset of the highlight of the fruits from their center, in units
+// of their radius: toward the top left or, with [Canvas.Lighting], toward the light.
+func (c *Canvas) fruitHighlight() Point {
+	if c.Lighting <= 0 {
+		return Point{X: -0.35, Y: -0.35}
+	}
+	lx, ly, _ := c.lightVector()
+	return Point{X: 0.5 * lx, Y: 0.5 * ly}
+}
+
+func newRadialShade(center, highlight Point, r float64, rgb tcolor.RGBColor) *radialShade {
+	return &radialShade{
+		cx:        center.X + highlight.X*r,
+		cy:        center.Y + highlight.Y*r,
+		r:         1.35 * r,
+		highlight: toRGBA(lerpColor(rgb, tcolor.RGBColor{R: 255, G: 255, B: 255}, 0.55)),
+		base:      toRGBA(rgb),
+		shadow:    toRGBA(lerpColor(rgb, tcolor.RGBColor{}, 0.45)),
+	}
+}
+
+func (s *radialShade) ColorModel() color.Model { return color.RGBAModel }
+
+func (s *radialShade) Bounds() image.Rectangle {
+	return image.Rect(-1e9, -1e9, 1e9, 1e9)
+}
+
+func (s *radialShade) At(x, y int) color.Color {
+	d := math.Hypot(float64(x)+0.5-s.cx, float64(y)+0.5-s.cy) / s.r
+	if d < 0.45 {
+		return lerpRGBA(s.highlight, s.base, d/0.45)
+	}
+	return lerpRGBA(s.base, s.shadow, min(1, (d-0.45)/0.55))
+}
+
+// minShadedRadius is the radius in pixels under which fruits are flat colored discs.
+const minShadedRadius = 4
+
+// drawFruits renders the fruits (growing with the leaves when growing) with their stem, not
+// in spring.
+func drawFruits(img draw.Image, c *Canvas, branches []*Branch, useLines bool) {
+	growth := c.leafGrowth()
+	if growth <= 0 || c.Season == Spring {
+		return
+	}
+	for i := range c.Fruits {
+		stem, center, r := c.fruitGeometry(i, branches, growth)
+		body := circle(center.X, center.Y, r)
+		// Top of the fruit, where the stem ends.
+		top := Point{X: center.X + (stem.X-center.X)/1.4, Y: center.Y + (stem.Y-center.Y)/1.4}
+		if useLines {
+			nimg := img.(*image.NRGBA)
+			ansipixels.DrawAALine(nimg, stem.X, stem.Y, top.X, top.Y, toNRGBA(stemColor))
+			for k := 0; k < len(body); k += 2 {
+				j := (k + 2) % len(body)
+				ansipixels.DrawAALine(nimg, body[k], body[k+1], body[j], body[j+1], toNRGBA(c.Fruit.Color))
+			}
+			continue
+		}
+		rimg := img.(*image.RGBA)
+		// Stem as a thin quad.
+		w := max(0.5, 0.1*r)
+		fillPolygon(rimg, []float64{stem.X - w, stem.Y, stem.X + w, stem.Y, top.X + w, top.Y, top.X - w, top.Y}, stemColor)
+		if r < minShadedRadius {
+			fillPolygon(rimg, body, c.Fruit.Color)
+			continue
+		}
+		fillPolygonSrc(rimg, body, newRadialShade(center, c.fruitHighlight(), r, c.Fruit.Color))
+	}
+}
@@ -0,0 +1,95 @@
+package ptree
+
+// Growth animation: branches are revealed level by level (a child starts growing once its
+// parent is fully grown) and then the leaves bud.
+
+// growthLevels computes (once per tree) the level of each branch in the hierarchy.
+func (c *Canvas) growthLevels() {
+	if len(c.levels) == len(c.Branches) {
+		return
+	}
+	c.levels = c.levels[:0]
+	c.maxLevel = 0
+	for _, b := range c.Branches {
+		level := 0
+		if b.Parent >= 0 {
+			level = c.levels[b.Parent] + 1
+		}
+		c.levels = append(c.levels, level)
+		c.maxLevel = max(c.maxLevel, level)
+	}
+}
+
+// leafBudding is the fraction of the growth animation, at the end, during which leaves bud.
+const leafBudding = 0.2
+
+// branchGrowth returns how much (0 to 1) of branch i is grown at the current [Canvas.Progress].
+func (c *Canvas) branchGrowth(i int) float64 {
+	if !c.Growing {
+		return 1
+	}
+	progress := c.Progress
+	if c.Leaves {
+		progress /= 1 - leafBudding
+	}
+	return min(1, max(0, progress*float64(c.maxLevel+1)-float64(c.levels[i])))
+}
+
+// leafGrowth returns the size factor (0 to 1) of the leaves once the branches are grown.
+func (c *Canvas) leafGrowth() float64 {
+	if !c.Growing {
+		return 1
+	}
+	return min(1, max(0, (c.Progress-1+leafBudding)/leafBudding))
+}
+
+// Partial returns a copy of the branch cut at fraction f of its length (tapering
+// accordingly), following the curve if the branch is curved.
+func (b *Branch) Partial(f float64) Branch {
+	nb := *b
+	nb.EndWidth = b.StartWidth + (b.EndWidth-b.StartWidth)*f
+	if !b.Curved {
+		nb.Length = b.Length * f
+		nb.SetEnd()
+		return nb
+	}
+	// De Casteljau split of the cubic at f, keeping the first part.
+	lerp := func(p, q Point) Point {
+		return Point{X: p.X + (q.X-p.X)*f, Y: p.Y + (q.Y-p.Y)*f}
+	}
+	p01 := lerp(b.Start, b.Ctrl1)
+	p12 := lerp(b.Ctrl1, b.Ctrl2)
+	p23 := lerp(b.Ctrl2, b.End)
+	p012 := lerp(p01, p12)
+	p123 := lerp(p12, p23)
+	nb.Ctrl1 = p01
+	nb.Ctrl2 = p012
+	nb.End = lerp(p012, p123)
+	nb.Length = b.Length * f
+	return nb
+}
+
+// grownBranches returns the branches as grown at the current [Canvas.Progress], with nil for
+// the ones not started yet.
+func (c *Canvas) grownBranches(branches []*Branch) []*Branch {
+	n := len(branches)
+	if cap(c.growBuf) < n {
+		c.growBuf = make([]Branch, n)
+		c.grown = make([]*Branch, n)
+	}
+	c.growBuf = c.growBuf[:n]
+	c.grown = c.grown[:n]
+	for i, b := range branches {
+		f := c.branchGrowth(i)
+		switch {
+		case f <= 0:
+			c.grown[i] = nil
+		case f >= 1:
+			c.grown[i] = b
+		default:
+			c.growBuf[i] = b.Partial(f)
+			c.grown[i] = &c.growBuf[i]
+		}
+	}
+	return c.grown
+}
@@ -0,0 +1,154 @@
+package ptree
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// LeafShape is the shape used to draw each leaf.
+type LeafShape int
+
+const (
+	LeafTriangle   LeafShape = iota
+	LeafNeedle               // Long and thin, e.g for pines and willows
+	LeafEllipse              // Broad oval leaf
+	LeafLanceolate           // Long narrow leaf tapering to a point, e.g willow
+	LeafMaple                // Five lobed (palmate) leaf
+	LeafPineFan              // Fan of 5 needles
+	LeafGinkgo               // Notched fan on a short stalk
+	LeafJuniper              // Round scaly pad lying flat (ignores the leaf angle)
+)
+
+var leafShapeNames = []string{"triangle", "needle", "ellipse", "lanceolate", "maple", "pine", "ginkgo", "juniper"}
+
+func (s LeafShape) String() string {
+	if s < 0 || int(s) >= len(leafShapeNames) {
+		return fmt.Sprintf("LeafShape(%d)", int(s))
+	}
+	return leafShapeNames[s]
+}
+
+// LeafShapeNames returns the names of the leaf shapes (for [ParseLeafShape]).
+func LeafShapeNames() []string {
+	return slices.Clone(leafShapeNames)
+}
+
+// ParseLeafShape returns the leaf shape for the given name.
+func ParseLeafShape(name string) (LeafShape, error) {
+	for i, n := range leafShapeNames {
+		if n == name {
+			return LeafShape(i), nil
+		}
+	}
+	return LeafTriangle, fmt.Errorf("unknown leaf shape %q, should be one of %v", name, leafShapeNames)
+}
+
+// minShapedLeaf is the leaf size in pixels under which the detailed shapes can't be seen (e.g
+// in half blocks) and are replaced by a simpler one, see [LeafShape.lowRes].
+const minShapedLeaf = 6
+
+// lowRes returns the shape to use for leaves too small to show details.
+func (s LeafShape) lowRes() LeafShape {
+	switch s {
+	case LeafPineFan, LeafLanceolate:
+		return LeafNeedle
+	case LeafMaple, LeafGinkgo:
+		return LeafTriangle
+	default:
+		return s
+	}
+}
+
+// leafPolygons returns the polygon(s) of a leaf attached at x, y and pointing toward angle.
+// sizeMultiplier includes the per leaf size variation. The triangle and needle shapes are a
+// single triangle: tip then the 2 points of the base (see [leafTriangle]).
+func leafPolygons(x, y, angle, branchWidth, sizeMultiplier float64, shape LeafShape) [][]float64 {
+	size := max(8, branchWidth*4) * sizeMultiplier // same as leafTriangle
+	if size < minShapedLeaf {
+		shape = shape.lowRes()
+	}
+	ca, sa := math.Cos(angle), math.Sin(angle)
+	// Point u along the leaf and v across it.
+	add := func(points []float64, u, v float64) []float64 {
+		return append(points, x+u*ca-v*sa, y+u*sa+v*ca)
+	}
+	switch shape {
+	case LeafEllipse:
+		const n = 14
+		points := make([]float64, 0, 2*n)
+		for k := range n {
+			t := math.Pi * (1 + 2*float64(k)/n) // starting at the stem
+			points = add(points, size*0.5*(1+math.Cos(t)), size*0.32*math.Sin(t))
+		}
+		return [][]float64{points}
+	case LeafLanceolate:
+		const n = 8
+		length := size * 1.4
+		points := make([]float64, 0, 4*n)
+		for k := 0; k <= n; k++ { // one side from the stem to the tip
+			t := float64(k) / n
+			points = add(points, length*t, size*0.16*math.Sin(math.Pi*t)*(1.2-0.4*t))
+		}
+		for k := n - 1; k > 0; k-- { // and back on the other side
+			t := float64(k) / n
+			points = add(points, length*t, -size*0.16*math.Sin(math.Pi*t)*(1.2-0.4*t))
+		}
+		return [][]float64{points}
+	case LeafMaple:
+		// 5 pointed lobes around the middle of the leaf, the 2 toward the stem shorter.
+		center := 0.5 * size
+		lobes := [5]float64{1, 0.85, 0.55, 0.55, 0.85}
+		points := make([]float64, 0, 2*20)
+		for k := range 5 {
+			a := float64(k) * 2 * math.Pi / 5
+			r := 0.5 * size * lobes[k]
+			// Shoulders, pointed tip, shoulders then the sinus between this lobe and the next.
+			for _, p := range [...][2]float64{{-0.22, 0.6}, {0, 1}, {0.22, 0.6}, {math.Pi / 5, 0.36 / lobes[k]}} {
+				points = add(points, center+r*p[1]*math.Cos(a+p[0]), r*p[1]*math.Sin(a+p[0]))
+			}
+		}
+		return [][]float64{points}
+	case LeafPineFan:
+		needles := make([][]float64, 0, 5)
+		for k := range 5 {
+			a := (float64(k) - 2) * 0.22
+			l := size * (1.3 - 0.1*math.Abs(float64(k)-2))
+			ua, va := math.Cos(a), math.Sin(a) // needle direction in the leaf frame
+			w := size * 0.05
+			needle := add(nil, l*ua, l*va)
+			needle = add(needle, -w*va, w*ua)
+			needles = append(needles, add(needle, w*va, -w*ua))
+		}
+		return needles
+	case LeafGinkgo:
+		// Short stalk then a fan with a notch in the middle of its outer edge.
+		const n = 12
+		stalk, w, spread := 0.3*size, 0.04*size, 0.9
+		points := make([]float64, 0, 2*(n+5))
+		points = add(add(points, 0, -w), stalk, -w)
+		for k := 0; k <= n; k++ {
+			a := spread * (2*float64(k)/n - 1)
+			r := 0.75 * size * (1 - 0.3*math.Exp(-a*a/0.02))
+			points = add(points, stalk+r*math.Cos(a), r*math.Sin(a))
+		}
+		return [][]float64{add(add(points, stalk, w), 0, w)}
+	case LeafJuniper:
+		// Flat pad with a bumpy top, in screen orientation, above the attachment point.
+		const n = 18
+		cx, cy := x+0.3*size*ca, y+0.3*size*sa-0.1*size
+		points := make([]float64, 0, 2*n)
+		for k := range n {
+			t := 2 * math.Pi * float64(k) / n
+			r := 1.0
+			if math.Sin(t) < 0 { // top half (y down)
+				r += 0.15 * math.Abs(math.Sin(4*t))
+			}
+			points = append(points, cx+0.55*size*r*math.Cos(t), cy+0.3*size*r*math.Sin(t))
+		}
+		return [][]float64{points}
+	default:
+		tip, base1, base2 := leafTriangle(x, y, angle, branchWidth, sizeMultiplier, shape)
+		return [][]float64{{tip.X, tip.Y, base1.X, base1.Y, base2.X, base2.Y}}
+	}
+}
@@ -0,0 +1,287 @@
+package ptree
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"fortio.org/terminal/ansipixels/tcolor"
+	"golang.org/x/image/vector"
+)
+
+// Lighting model: the light comes from [Canvas.LightDirection], a bit from the front, and branches are
+// shaded as cylinders (lit side, shadow side) while leaves are lighter on the sunny side of
+// the crown. [Canvas.Lighting] is the strength of the effect (0 = flat colors).
+const (
+	lightFront  = 0.8 // Component of the light toward the viewer (before normalization)
+	lightFlat   = 0.6 // Intensity at which the base color is used unchanged
+	shadeLevels = 32  // Number of precomputed shades per branch
+	// lowResLight is the image width under which branches get a single (lit or shaded) color
+	// instead of a gradient across their width, too thin to show it.
+	lowResLight = 200
+)
+
+var (
+	lightWhite = tcolor.RGBColor{R: 255, G: 255, B: 255}
+	lightBlack = tcolor.RGBColor{}
+)
+
+// lightVector returns the unit light direction in image coordinates (Y down, Z toward the viewer).
+func (c *Canvas) lightVector() (lx, ly, lz float64) {
+	sun := c.LightDirection
+	l := math.Sqrt(sun.X*sun.X + sun.Y*sun.Y + lightFront*lightFront)
+	return sun.X / l, -sun.Y / l, lightFront / l
+}
+
+// shadeColor returns rgb lit with the given intensity (0 in the shadow to 1 fully lit).
+func (c *Canvas) shadeColor(rgb tcolor.RGBColor, intensity float64) tcolor.RGBColor {
+	if intensity < lightFlat {
+		return lerpColor(rgb, lightBlack, c.Lighting*0.6*(lightFlat-intensity)/lightFlat)
+	}
+	return lerpColor(rgb, lightWhite, c.Lighting*0.35*(intensity-lightFlat)/(1-lightFlat))
+}
+
+// crownSide returns where p is in the crown of the tree relative to the light: 1 on the side
+// facing the light, -1 on the opposite side.
+func (c *Canvas) crownSide(p Point) float64 {
+	lx, ly, _ := c.lightVector()
+	l := math.Hypot(lx, ly)
+	if l == 0 || c.crownRadius == 0 {
+		return 0
+	}
+	d := (p.X-c.crown.X)*lx/l + (p.Y-c.crown.Y)*ly/l
+	return max(-1, min(1, d/c.crownRadius))
+}
+
+// computeCrown sets the center and radius of the bounding box of the tree.
+func (c *Canvas) computeCrown() {
+	if len(c.Branches) == 0 {
+		c.crownRadius = 0
+		return
+	}
+	x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, b := range c.Branches {
+		for _, p := range [...]Point{b.Start, b.End} {
+			x0, y0, x1, y1 = min(x0, p.X), min(y0, p.Y), max(x1, p.X), max(y1, p.Y)
+		}
+	}
+	c.crown = Point{X: (x0 + x1) / 2, Y: (y0 + y1) / 2}
+	c.crownRadius = max(1, math.Max(x1-x0, y1-y0)/2)
+}
+
+// litLeaf returns the color of leaf i (base color rgb at position pos) lit according to its
+// side of the crown, with some variation as leaves face different directions.
+func (c *Canvas) litLeaf(i int, pos Point, rgb tcolor.RGBColor) tcolor.RGBColor {
+	if c.Lighting <= 0 {
+		return rgb
+	}
+	return c.shadeColor(rgb, lightFlat+0.3*c.crownSide(pos)+0.3*(leafRandom(i, 4)-0.5))
+}
+
+// branchSource returns the fill of branch i (b) of color rgb: uniform without lighting (or at
+// low resolution, lit according to its side of the crown) nor bark texture, else shaded across
+// its width and/or textured. The shades are kept per tree and resolution, only following the
+// branch when it moves (wind, growth).
+func (c *Canvas) branchSource(i int, b *Branch, rgb tcolor.RGBColor, imgWidth int) image.Image {
+	bark := c.barkTextured(b, imgWidth)
+	if c.Lighting <= 0 && !bark {
+		return image.NewUniform(toRGBA(rgb))
+	}
+	if imgWidth < lowResLight {
+		return image.NewUniform(toRGBA(c.litBranch(b, rgb)))
+	}
+	if c.shadeWidth != imgWidth || len(c.shades) != len(c.Branches) {
+		c.shades = append(c.shades[:0], make([]*branchShade, len(c.Branches))...)
+		c.shadeWidth = imgWidth
+	}
+	s := c.shades[i]
+	if s == nil || s.rgb != rgb {
+		s = c.newBranchShade(rgb)
+		if bark {
+			s.bark = c.Bark
+			s.seed = float64(i)
+		}
+		c.shades[i] = s
+	}
+	s.follow(b)
+	return s
+}
+
+// litBranch returns the single color of branch b at low resolution (or drawn as a line): lit
+// according to its side of the crown.
+func (c *Canvas) litBranch(b *Branch, rgb tcolor.RGBColor) tcolor.RGBColor {
+	if c.Lighting <= 0 {
+		return rgb
+	}
+	return c.shadeColor(rgb, lightFlat+0.4*c.crownSide(b.PointAt(0.5)))
+}
+
+// cylinderLight returns the light intensity at u across the width of a branch (-1 on one edge,
+// 1 on the other) whose perpendicular faces the light by facing: Lambert on the surface normal
+// of a cylinder, u across and the rest toward the viewer.
+func cylinderLight(u, facing, lz float64) float64 {
+	return max(0, u*facing+math.Sqrt(1-u*u)*lz)
+}
+
+// branchShade is a cylinder like shading across the width of a (possibly curved) branch,
+// optionally with a bark texture following the branch.
+type branchShade struct {
+	shape  branchShape // Of the branch the samples are for
+	axis   []Point     // Samples along the branch
+	half   []float64   // Half width at each sample
+	dist   []float64   // Distance along the branch of each sample
+	facing []float64   // How much the perpendicular of each segment faces the light
+	boxes  []box       // Bounding box of each segment
+	hint   int         // Closest segment of the last pixel, likely the one of the next
+	lx, ly float64
+	lz     float64
+	rgb    tcolor.RGBColor             // Base color
+	ramp   [shadeLevels + 1]color.RGBA // Colors from the darkest to the most lit
+	bark   BarkStyle
+	seed   float64      // Bark pattern variation
+	mask   *image.Alpha // Coverage of the branch, see [branchShade.draw]
+}
+
+// branchShape is what the shade of a branch depends on.
+type branchShape struct {
+	start, end, ctrl1, ctrl2 Point
+	curved                   bool
+	startWidth, endWidth     float64
+}
+
+type box struct {
+	minX, minY, maxX, maxY float64
+}
+
+// newBranchShade returns the shade for branch color rgb, see [branchShade.follow] for the geometry.
+func (c *Canvas) newBranchShade(rgb tcolor.RGBColor) *branchShade {
+	s := &branchShade{rgb: rgb}
+	s.lx, s.ly, s.lz = c.lightVector()
+	for k := range s.ramp {
+		intensity := float64(k) / shadeLevels
+		if c.Lighting <= 0 {
+			intensity = lightFlat // bark texture only
+		}
+		s.ramp[k] = toRGBA(c.shadeColor(rgb, intensity))
+	}
+	return s
+}
+
+// follow samples the axis of branch b (when it changed since the last time).
+func (s *branchShade) follow(b *Branch) {
+	shape := branchShape{b.Start, b.End, b.Ctrl1, b.Ctrl2, b.Curved, b.StartWidth, b.EndWidth}
+	if shape == s.shape && len(s.axis) > 0 {
+		return
+	}
+	s.shape = shape
+	n := b.curveSamples()
+	s.axis, s.half = s.axis[:0], s.half[:0]
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		s.axis = append(s.axis, b.PointAt(t))
+		s.half = append(s.half, max(0.5, (b.StartWidth+(b.EndWidth-b.StartWidth)*t)/2))
+	}
+	s.dist = append(s.dist[:0], 0)
+	s.facing, s.boxes = s.facing[:0], s.boxes[:0]
+	for i := range n {
+		a, e := s.axis[i], s.axis[i+1]
+		dx, dy := e.X-a.X, e.Y-a.Y
+		l := max(1e-9, math.Hypot(dx, dy))
+		s.facing = append(s.facing, (-dy*s.lx+dx*s.ly)/l)
+		s.dist = append(s.dist, s.dist[i]+l)
+		s.boxes = append(s.boxes, box{min(a.X, e.X), min(a.Y, e.Y), max(a.X, e.X), max(a.Y, e.Y)})
+	}
+	s.hint = 0
+}
+
+func (s *branchShade) ColorModel() color.Model { return color.RGBAModel }
+
+func (s *branchShade) Bounds() image.Rectangle {
+	return image.Rect(-1e9, -1e9, 1e9, 1e9)
+}
+
+func (s *branchShade) At(x, y int) color.Color {
+	px, py := float64(x)+0.5, float64(y)+0.5
+	// Closest segment of the axis: starting with the one of the previous pixel, the others
+	// only when their bounding box is closer.
+	best := s.hint
+	bestT, bestD := s.segmentDistance(best, px, py)
+	for i, bb := range s.boxes {
+		ox := max(bb.minX-px, 0, px-bb.maxX)
+		oy := max(bb.minY-py, 0, py-bb.maxY)
+		if i == s.hint || ox*ox+oy*oy >= bestD {
+			continue
+		}
+		if t, d := s.segmentDistance(i, px, py); d < bestD {
+			best, bestT, bestD = i, t, d
+		}
+	}
+	s.hint = best
+	a, b := s.axis[best], s.axis[best+1]
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := max(1e-9, math.Hypot(dx, dy))
+	// Signed position across the width: -1 on one edge, 1 on the other.
+	across := ((px-a.X)*-dy + (py-a.Y)*dx) / l
+	half := s.half[best] + (s.half[best+1]-s.half[best])*bestT
+	u := max(-1, min(1, across/half))
+	clr := s.ramp[int(math.Round(cylinderLight(u, s.facing[best], s.lz)*shadeLevels))]
+	if s.bark == BarkFlat {
+		return clr
+	}
+	along := (s.dist[best] + bestT*(s.dist[best+1]-s.dist[best])) / s.half[0]
+	v := s.bark.value(along, math.Asin(u), s.seed)
+	if v < 0 {
+		return lerpRGBA(clr, color.RGBA{A: 255}, -0.7*v)
+	}
+	return lerpRGBA(clr, color.RGBA{R: 235, G: 225, B: 205, A: 255}, 0.6*v)
+}
+
+// draw fills the path of rast over img (the rasterizer bounds) with the shade: the coverage
+// first so the shade, costlier than the rasterization, is only computed for the covered pixels.
+func (s *branchShade) draw(img *image.RGBA, rast *vector.Rasterizer) {
+	r := img.Bounds()
+	w, h := r.Dx(), r.Dy()
+	if s.mask == nil || cap(s.mask.Pix) < w*h {
+		s.mask = image.NewAlpha(image.Rect(0, 0, w, h))
+	} else {
+		s.mask = &image.Alpha{Pix: s.mask.Pix[:w*h], Stride: w, Rect: image.Rect(0, 0, w, h)}
+	}
+	op := rast.DrawOp
+	rast.DrawOp = draw.Src
+	rast.Draw(s.mask, s.mask.Rect, image.Opaque, image.Point{})
+	rast.DrawOp = op
+	for y := range h {
+		for x := range w {
+			ma := uint32(s.mask.Pix[y*w+x])
+			if ma == 0 {
+				continue
+			}
+			c := s.At(r.Min.X+x, r.Min.Y+y).(color.RGBA)
+			d := img.Pix[img.PixOffset(r.Min.X+x, r.Min.Y+y):][:4]
+			// Over with an opaque source: d = c*ma + d*(1-ma).
+			d[0] = uint8((uint32(c.R)*ma + uint32(d[0])*(255-ma)) / 255)
+			d[1] = uint8((uint32(c.G)*ma + uint32(d[1])*(255-ma)) / 255)
+			d[2] = uint8((uint32(c.B)*ma + uint32(d[2])*(255-ma)) / 255)
+			d[3] = uint8((255*ma + uint32(d[3])*(255-ma)) / 255)
+		}
+	}
+}
+
+// segmentDistance returns the position t (0 to 1) of the closest point of segment i of the
+// axis to px, py and the squared distance to it.
+func (s *branchShade) segmentDistance(i int, px, py float64) (t, d float64) {
+	a, b := s.axis[i], s.axis[i+1]
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = max(0, min(1, ((px-a.X)*dx+(py-a.Y)*dy)/l2))
+	}
+	ex, ey := px-a.X-t*dx, py-a.Y-t*dy
+	return t, ex*ex + ey*ey
+}
+
+// lerpRGBA returns the (opaque) color between a (f = 0) and b (f = 1).
+func lerpRGBA(a, b color.RGBA, f float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*f) }
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 255}
+}
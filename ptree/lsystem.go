@@ -0,0 +1,464 @@
+package ptree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LRule is a single L-system production. Several rules with the same predecessor
+// make the production stochastic: one of them is picked at random, proportionally
+// to its Weight (a 0 weight counts as 1, negative ones are invalid).
+// Parametric rules use a named parameter in the predecessor, for instance
+// `F(x) -> F(x*0.8)[+F(x*0.6)]`, parameters in the successor are either a number,
+// the variable, or the variable multiplied by a number.
+type LRule struct {
+	Pred   string // Predecessor (single) symbol, optionally with a parameter name e.g "F" or "F(x)"
+	Succ   string // Successor string
+	Weight float64
+}
+
+// LSystem describes a full grammar: axiom, rules and turtle settings.
+type LSystem struct {
+	Name       string
+	Axiom      string
+	Rules      []LRule
+	Iterations int
+	Angle      float64 // Turn angle in degrees for + and - (0 = 25°, a 0 turn must use +(0) parameters)
+}
+
+// module is one symbol of an expanded L-system string with its optional parameter.
+type module struct {
+	sym      byte
+	param    float64
+	hasParam bool
+}
+
+// compiledRule is an LRule with its successor pre-parsed.
+type compiledRule struct {
+	varName string
+	succ    []succModule
+	weight  float64
+}
+
+// succModule is a successor symbol whose parameter is param*(x if useVar) or just param.
+type succModule struct {
+	sym      byte
+	param    float64
+	hasParam bool
+	useVar   bool
+}
+
+// maxLSystemModules caps the expansion so a bad grammar or too many iterations can't eat all the memory.
+const maxLSystemModules = 1 << 20
+
+// Built-in grammars, usable by name with [BuiltinLSystem].
+var builtinLSystems = []*LSystem{
+	{
+		Name:       "binary",
+		Axiom:      "F(1)",
+		Rules:      []LRule{{Pred: "F(x)", Succ: "F(x)[+F(x*0.7)][-F(x*0.7)]"}},
+		Iterations: 7,
+		Angle:      30,
+	},
+	{
+		Name:  "bush",
+		Axiom: "F",
+		Rules: []LRule{
+			{Pred: "F", Succ: "FF-[-F+F+F]+[+F-F-F]", Weight: 2},
+			{Pred: "F", Succ: "FF+[+F-F]-[-F+F]", Weight: 1},
+		},
+		Iterations: 4,
+		Angle:      22.5,
+	},
+	{
+		Name:  "fern",
+		Axiom: "X",
+		Rules: []LRule{
+			{Pred: "X", Succ: "F+[[X]-X]-F[-FX]+X"},
+			{Pred: "F", Succ: "FF"},
+		},
+		Iterations: 6,
+		Angle:      25,
+	},
+}
+
+// BuiltinLSystemNames returns the names of the built-in grammars.
+func BuiltinLSystemNames() []string {
+	names := make([]string, 0, len(builtinLSystems))
+	for _, l := range builtinLSystems {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// BuiltinLSystem returns the built-in grammar with the given name or nil if there is none.
+func BuiltinLSystem(name string) *LSystem {
+	for _, l := range builtinLSystems {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// ParseLSystem reads a grammar in the following text format:
+//
+//	# comment
+//	axiom: X
+//	angle: 25
+//	iterations: 6
+//	X -> F+[[X]-X]-F[-FX]+X
+//	F (0.7) -> FF
+//	F (0.3) -> F
+//
+// The optional number in parenthesis after the predecessor is the rule weight (for stochastic rules).
+// The angle can't be 0 (which means the default 25°).
+func ParseLSystem(name string, r io.Reader) (*LSystem, error) {
+	l := &LSystem{Name: name}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if pred, succ, found := strings.Cut(line, "->"); found {
+			rule, err := parseRuleLine(pred, succ)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			l.Rules = append(l.Rules, rule)
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected 'key: value' or 'pred -> succ', got %q", lineNum, line)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "axiom":
+			l.Axiom = value
+		case "angle":
+			l.Angle, err = strconv.ParseFloat(value, 64)
+			if err == nil && l.Angle == 0 {
+				err = fmt.Errorf("angle can't be 0 (the default 25° when not set)")
+			}
+		case "iterations":
+			l.Iterations, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if l.Axiom == "" {
+		return nil, fmt.Errorf("missing axiom in %s", name)
+	}
+	return l, nil
+}
+
+// parseRuleLine parses the `pred [(weight)] -> succ` rule syntax.
+func parseRuleLine(pred, succ string) (LRule, error) {
+	pred = strings.TrimSpace(pred)
+	rule := LRule{Succ: strings.TrimSpace(succ)}
+	// Weight is separated by a space from the predecessor (which can itself have a parameter).
+	if p, w, found := strings.Cut(pred, " "); found {
+		w = strings.Trim(strings.TrimSpace(w), "()")
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return rule, fmt.Errorf("invalid rule weight %q: %w", w, err)
+		}
+		rule.Weight = weight
+		pred = p
+	}
+	if pred == "" {
+		return rule, fmt.Errorf("empty rule predecessor")
+	}
+	rule.Pred = pred
+	return rule, nil
+}
+
+// parseModules parses an axiom (parameters must be numbers) into modules.
+func parseModules(s string) ([]module, error) {
+	succ, err := parseSuccessor(s, "")
+	if err != nil {
+		return nil, err
+	}
+	res := make([]module, len(succ))
+	for i, sm := range succ {
+		res[i] = module{sym: sm.sym, param: sm.param, hasParam: sm.hasParam}
+	}
+	return res, nil
+}
+
+// parseSuccessor parses a string of symbols with optional `(param)` where param can
+// reference varName.
+func parseSuccessor(s, varName string) ([]succModule, error) {
+	var res []succModule
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' {
+			continue
+		}
+		m := succModule{sym: c}
+		if i+1 < len(s) && s[i+1] == '(' {
+			end := strings.IndexByte(s[i+1:], ')')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated parameter in %q", s)
+			}
+			expr := s[i+2 : i+1+end]
+			if err := m.parseParam(expr, varName); err != nil {
+				return nil, err
+			}
+			i += end + 1
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// parseParam handles `1.5`, `x` and `x*0.8` (or `0.8*x`) parameter expressions.
+func (m *succModule) parseParam(expr, varName string) error {
+	m.hasParam = true
+	m.param = 1
+	for factor := range strings.SplitSeq(expr, "*") {
+		factor = strings.TrimSpace(factor)
+		if varName != "" && factor == varName {
+			m.useVar = true
+			continue
+		}
+		v, err := strconv.ParseFloat(factor, 64)
+		if err != nil {
+			return fmt.Errorf("invalid parameter expression %q: %w", expr, err)
+		}
+		m.param *= v
+	}
+	return nil
+}
+
+func compileRules(rules []LRule) (map[byte][]compiledRule, error) {
+	res := make(map[byte][]compiledRule)
+	for _, r := range rules {
+		if r.Pred == "" {
+			return nil, fmt.Errorf("empty rule predecessor")
+		}
+		sym := r.Pred[0]
+		varName := ""
+		if len(r.Pred) > 1 {
+			rest := strings.TrimSpace(r.Pred[1:])
+			if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+				return nil, fmt.Errorf("invalid rule predecessor %q: should be a single symbol with an optional (parameter)", r.Pred)
+			}
+			varName = strings.TrimSpace(rest[1 : len(rest)-1])
+			if varName == "" || strings.ContainsAny(varName, "()*") {
+				return nil, fmt.Errorf("invalid parameter name in rule predecessor %q", r.Pred)
+			}
+		}
+		succ, err := parseSuccessor(r.Succ, varName)
+		if err != nil {
+			return nil, err
+		}
+		weight := r.Weight
+		if weight < 0 {
+			return nil, fmt.Errorf("invalid negative weight %g for rule %s -> %s", weight, r.Pred, r.Succ)
+		}
+		if weight == 0 {
+			weight = 1
+		}
+		res[sym] = append(res[sym], compiledRule{varName: varName, succ: succ, weight: weight})
+	}
+	return res, nil
+}
+
+// pickRule picks one of the (stochastic) rules according to their weights.
+func (c *Canvas) pickRule(rules []compiledRule) *compiledRule {
+	if len(rules) == 1 {
+		return &rules[0]
+	}
+	total := 0.0
+	for _, r := range rules {
+		total += r.weight
+	}
+	v := c.Rand.Float64() * total
+	for i := range rules {
+		v -= rules[i].weight
+		if v < 0 {
+			return &rules[i]
+		}
+	}
+	return &rules[len(rules)-1]
+}
+
+// Validate checks the axiom and rules can be parsed.
+func (l *LSystem) Validate() error {
+	if _, err := parseModules(l.Axiom); err != nil {
+		return err
+	}
+	_, err := compileRules(l.Rules)
+	return err
+}
+
+// expandLSystem applies the rules iterations times to the axiom.
+func (c *Canvas) expandLSystem(axiom string, rules []LRule, iterations int) ([]module, error) {
+	current, err := parseModules(axiom)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	for range iterations {
+		next, ok := c.expandOnce(current, compiled)
+		if !ok {
+			break // keep the last expansion that fit
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// expandOnce applies the rules once to current, it returns false as soon as the result would
+// exceed maxLSystemModules.
+func (c *Canvas) expandOnce(current []module, compiled map[byte][]compiledRule) ([]module, bool) {
+	next := make([]module, 0, min(maxLSystemModules, 2*len(current)))
+	for _, m := range current {
+		candidates := compiled[m.sym]
+		if len(candidates) == 0 {
+			if len(next) >= maxLSystemModules {
+				return nil, false
+			}
+			next = append(next, m)
+			continue
+		}
+		rule := c.pickRule(candidates)
+		if len(next)+len(rule.succ) > maxLSystemModules {
+			return nil, false
+		}
+		x := 1.0
+		if m.hasParam {
+			x = m.param
+		}
+		for _, s := range rule.succ {
+			nm := module{sym: s.sym, param: s.param, hasParam: s.hasParam}
+			if s.useVar {
+				nm.param *= x
+			}
+			next = append(next, nm)
+		}
+	}
+	return next, true
+}
+
+// GenerateLSystem generates the tree by expanding the axiom of l with its rules for its number of
+// iterations and interpreting the result with a turtle:
+//
+//	F, G  draw forward (parameter scales the step length)
+//	f     move forward without drawing
+//	+ -   turn left/right by the l angle (or the parameter in degrees)
+//	|     turn around
+//	[ ]   push/pop the turtle state
+//
+// Other symbols are ignored by the turtle (but can be rewritten by rules).
+// The resulting segments are regular [Branch] in [Canvas.Branches], scaled to fit the canvas,
+// with widths computed back from the tips and depths normalized to [Canvas.MaxDepth].
+func (c *Canvas) GenerateLSystem(l *LSystem) error {
+	c.Branches = c.Branches[:0]
+	modules, err := c.expandLSystem(l.Axiom, l.Rules, l.Iterations)
+	if err != nil {
+		return err
+	}
+	angleDeg := 25.0
+	if l.Angle != 0 {
+		angleDeg = l.Angle
+	}
+	turn := angleDeg * math.Pi / 180 * c.Spread
+	type turtle struct {
+		pos    Point
+		angle  float64
+		parent int // index of the last drawn segment, -1 for none
+	}
+	t := turtle{angle: math.Pi / 2, parent: -1}
+	var stack []turtle
+	var parents []int
+	for _, m := range modules {
+		step := 1.0
+		if m.hasParam {
+			step = m.param
+		}
+		switch m.sym {
+		case 'F', 'G':
+			b := &Branch{Start: t.pos, Angle: t.angle, Length: step, Rand: c.Rand, Spread: c.Spread}
+			b.SetEnd()
+			c.Branches = append(c.Branches, b)
+			parents = append(parents, t.parent)
+			t.parent = len(c.Branches) - 1
+			t.pos = b.End
+			t.angle = bend(t.angle, c.Gravity, c.phototropism(), 0.03)
+		case 'f':
+			t.pos.X += step * math.Cos(t.angle)
+			t.pos.Y -= step * math.Sin(t.angle)
+		case '+', '-':
+			delta := turn
+			if m.hasParam {
+				delta = m.param * math.Pi / 180
+			}
+			if m.sym == '-' {
+				delta = -delta
+			}
+			t.angle += delta + (c.Rand.Float64()-0.5)*math.Pi/40*c.Spread
+		case '|':
+			t.angle += math.Pi
+		case '[':
+			stack = append(stack, t)
+		case ']':
+			if len(stack) > 0 {
+				t = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if len(c.Branches) == 0 {
+		return nil
+	}
+	c.fitToCanvas()
+	c.assignPipeWidths(parents, float64(c.Width)*c.TrunkWidthPct/100.0, daVinciExponent)
+	if c.Curves {
+		c.curveSegments(parents)
+	}
+	return nil
+}
+
+// fitToCanvas scales and translates the branches (generated in arbitrary units) so they fit
+// in the canvas, centered horizontally and resting on the bottom edge.
+func (c *Canvas) fitToCanvas() {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, b := range c.Branches {
+		minX = min(minX, b.Start.X, b.End.X)
+		maxX = max(maxX, b.Start.X, b.End.X)
+		minY = min(minY, b.Start.Y, b.End.Y)
+		maxY = max(maxY, b.Start.Y, b.End.Y)
+	}
+	bw := max(maxX-minX, 1e-9)
+	bh := max(maxY-minY, 1e-9)
+	scale := min(0.9*float64(c.Width)/bw, 0.95*float64(c.Height)/bh)
+	offX := float64(c.Width)/2 - 0.5 - scale*(minX+maxX)/2
+	offY := float64(c.Height) - scale*maxY
+	for _, b := range c.Branches {
+		b.Start.X = offX + scale*b.Start.X
+		b.Start.Y = offY + scale*b.Start.Y
+		b.Length = b.Length*scale + 0.75 // slight overlap with the next segment to avoid anti-aliasing seams
+		b.SetEnd()
+	}
+}
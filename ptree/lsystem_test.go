@@ -0,0 +1,244 @@
+package ptree
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"fortio.org/rand"
+)
+
+func TestParseLSystem(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string // expected error substring, "" for none
+		want  LSystem
+	}{
+		{
+			name:  "full",
+			input: "# comment\naxiom: X\nangle: 22.5\niterations: 5\n\nX -> F[+X]F[-X]+X\nF (0.7) -> FF\nF (0.3) -> F\n",
+			want: LSystem{
+				Axiom: "X", Angle: 22.5, Iterations: 5,
+				Rules: []LRule{
+					{Pred: "X", Succ: "F[+X]F[-X]+X"},
+					{Pred: "F", Succ: "FF", Weight: 0.7},
+					{Pred: "F", Succ: "F", Weight: 0.3},
+				},
+			},
+		},
+		{
+			name:  "parametric",
+			input: "Axiom: F(1)\nF(x) -> F(x*0.8)[+F(x*0.6)]",
+			want:  LSystem{Axiom: "F(1)", Rules: []LRule{{Pred: "F(x)", Succ: "F(x*0.8)[+F(x*0.6)]"}}},
+		},
+		{name: "missing axiom", input: "F -> FF", err: "missing axiom"},
+		{name: "not a rule", input: "axiom: F\nFF", err: "line 2: expected"},
+		{name: "unknown key", input: "axiom: F\ncolor: red", err: `unknown key "color"`},
+		{name: "bad angle", input: "axiom: F\nangle: wide", err: "line 2"},
+		{name: "zero angle", input: "axiom: F\nangle: 0", err: "angle can't be 0"},
+		{name: "bad iterations", input: "axiom: F\niterations: 2.5", err: "line 2"},
+		{name: "bad weight", input: "axiom: F\nF (abc) -> FF", err: `invalid rule weight "abc"`},
+		{name: "empty predecessor", input: "axiom: F\n -> FF", err: "empty rule predecessor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := ParseLSystem(tt.name, strings.NewReader(tt.input))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseLSystem() error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLSystem() unexpected error: %v", err)
+			}
+			if l.Axiom != tt.want.Axiom || l.Angle != tt.want.Angle || l.Iterations != tt.want.Iterations {
+				t.Errorf("ParseLSystem() = %+v, want %+v", l, tt.want)
+			}
+			if len(l.Rules) != len(tt.want.Rules) {
+				t.Fatalf("got %d rules, want %d", len(l.Rules), len(tt.want.Rules))
+			}
+			for i, r := range l.Rules {
+				if r != tt.want.Rules[i] {
+					t.Errorf("rule %d = %+v, want %+v", i, r, tt.want.Rules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		axiom string
+		rules []LRule
+		err   string
+	}{
+		{name: "builtin like", axiom: "F(1)", rules: []LRule{{Pred: "F(x)", Succ: "F(x)[+F(x*0.7)]"}}},
+		{name: "unterminated axiom", axiom: "F(1", err: "unterminated parameter"},
+		{name: "variable in axiom", axiom: "F(x)", err: "invalid parameter expression"},
+		{name: "unknown variable", axiom: "F(1)", rules: []LRule{{Pred: "F(x)", Succ: "F(y*2)"}}, err: `"y*2"`},
+		{name: "unterminated successor", axiom: "F", rules: []LRule{{Pred: "F", Succ: "F(2"}}, err: "unterminated"},
+		{name: "multi symbols predecessor", axiom: "F", rules: []LRule{{Pred: "AB", Succ: "F"}}, err: "single symbol"},
+		{name: "empty parameter name", axiom: "F", rules: []LRule{{Pred: "F()", Succ: "F"}}, err: "invalid parameter name"},
+		{name: "zero weight", axiom: "F", rules: []LRule{{Pred: "F", Succ: "FF", Weight: 0}, {Pred: "F", Succ: "F", Weight: 2}}},
+		{name: "negative weight", axiom: "F", rules: []LRule{{Pred: "F", Succ: "FF", Weight: -1}}, err: "negative weight"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&LSystem{Axiom: tt.axiom, Rules: tt.rules}).Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Validate() unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Validate() error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// countSymbols returns how many modules of each symbol there are.
+func countSymbols(modules []module) map[byte]int {
+	res := make(map[byte]int)
+	for _, m := range modules {
+		res[m.sym]++
+	}
+	return res
+}
+
+func TestExpandLSystemCounts(t *testing.T) {
+	fern := BuiltinLSystem("fern")
+	// X -> F+[[X]-X]-F[-FX]+X and F -> FF: X(n+1) = 4 X(n), F(n+1) = 2 F(n) + 3 X(n).
+	tests := []struct {
+		iterations int
+		x, f       int
+	}{
+		{0, 1, 0},
+		{1, 4, 3},
+		{2, 16, 18},
+		{3, 64, 84},
+		{4, 256, 360},
+	}
+	for _, tt := range tests {
+		c := &Canvas{Rand: rand.New(1)}
+		modules, err := c.expandLSystem(fern.Axiom, fern.Rules, tt.iterations)
+		if err != nil {
+			t.Fatalf("expandLSystem(%d) unexpected error: %v", tt.iterations, err)
+		}
+		counts := countSymbols(modules)
+		if counts['X'] != tt.x || counts['F'] != tt.f {
+			t.Errorf("expandLSystem(%d) got %d X and %d F, want %d and %d",
+				tt.iterations, counts['X'], counts['F'], tt.x, tt.f)
+		}
+	}
+}
+
+func TestExpandLSystemCap(t *testing.T) {
+	c := &Canvas{Rand: rand.New(1)}
+	// 10^6 modules after 6 iterations, the 7th would exceed the cap.
+	modules, err := c.expandLSystem("F", []LRule{{Pred: "F", Succ: "FFFFFFFFFF"}}, 20)
+	if err != nil {
+		t.Fatalf("expandLSystem() unexpected error: %v", err)
+	}
+	if len(modules) != 1_000_000 {
+		t.Errorf("expandLSystem() got %d modules, want the last expansion under the cap (1000000)", len(modules))
+	}
+}
+
+func TestExpandLSystemParameters(t *testing.T) {
+	c := &Canvas{Rand: rand.New(1)}
+	rules := []LRule{{Pred: "F(x)", Succ: "F(x*0.5)[+(30)F(0.6*x)]G(2)"}}
+	modules, err := c.expandLSystem("F(4)", rules, 2)
+	if err != nil {
+		t.Fatalf("expandLSystem() unexpected error: %v", err)
+	}
+	type want struct {
+		sym   byte
+		param float64
+	}
+	var got []want
+	for _, m := range modules {
+		if m.hasParam {
+			got = append(got, want{m.sym, m.param})
+		}
+	}
+	// F(4) -> F(2)[+(30)F(2.4)]G(2) -> F(1)[+(30)F(1.2)]G(2)[+(30)F(1.2)[+(30)F(1.44)]G(2)]G(2)
+	expected := []want{
+		{'F', 1}, {'+', 30}, {'F', 1.2}, {'G', 2},
+		{'+', 30}, {'F', 1.2}, {'+', 30}, {'F', 1.44}, {'G', 2}, {'G', 2},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("got %d parametric modules %v, want %d %v", len(got), got, len(expected), expected)
+	}
+	for i := range got {
+		if got[i].sym != expected[i].sym || !almostEqual(got[i].param, expected[i].param) {
+			t.Errorf("module %d = %c(%g), want %c(%g)", i, got[i].sym, got[i].param, expected[i].sym, expected[i].param)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+// newTestLSystemCanvas returns a canvas generating the named built-in grammar with the given seed.
+func newTestLSystemCanvas(name string, seed uint64) *Canvas {
+	return &Canvas{
+		Width:         200,
+		Height:        150,
+		MaxDepth:      6,
+		Spread:        1,
+		TrunkWidthPct: 4,
+		Rand:          rand.New(seed),
+		LSystem:       BuiltinLSystem(name),
+	}
+}
+
+func TestGenerateLSystemDeterministic(t *testing.T) {
+	for _, name := range BuiltinLSystemNames() {
+		t.Run(name, func(t *testing.T) {
+			c1, c2 := newTestLSystemCanvas(name, 42), newTestLSystemCanvas(name, 42)
+			if err := c1.Generate(); err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if err := c2.Generate(); err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if len(c1.Branches) == 0 {
+				t.Fatal("Generate() produced no branches")
+			}
+			if len(c1.Branches) != len(c2.Branches) {
+				t.Fatalf("same seed gave %d and %d branches", len(c1.Branches), len(c2.Branches))
+			}
+			for i := range c1.Branches {
+				if c1.Branches[i].End != c2.Branches[i].End {
+					t.Fatalf("branch %d differs: %v vs %v", i, c1.Branches[i].End, c2.Branches[i].End)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidLSystem(t *testing.T) {
+	c := newTestLSystemCanvas("binary", 1)
+	c.LSystem = &LSystem{Axiom: "F(1", Iterations: 2}
+	if err := c.Generate(); err == nil {
+		t.Error("Generate() with an invalid axiom should fail")
+	}
+}
+
+func TestGenerateLSystemAngle(t *testing.T) {
+	c := newTestLSystemCanvas("binary", 1)
+	c.LSystem = nil // the angle comes from the argument
+	if err := c.GenerateLSystem(&LSystem{Axiom: "F+F", Angle: 90}); err != nil {
+		t.Fatalf("GenerateLSystem() unexpected error: %v", err)
+	}
+	if len(c.Branches) != 2 {
+		t.Fatalf("got %d branches, want 2", len(c.Branches))
+	}
+	// Up then turned left by 90° (give or take the random wiggle).
+	if got := c.Branches[1].Angle - c.Branches[0].Angle; math.Abs(got-math.Pi/2) > math.Pi/80 {
+		t.Errorf("turn = %g, want about %g", got, math.Pi/2)
+	}
+}
@@ -0,0 +1,68 @@
+package ptree
+
+import "math"
+
+// Exponents of the tips ratio for [Canvas.assignPipeWidths].
+const (
+	// daVinciExponent is the da Vinci rule: the cross section area is conserved at each fork.
+	daVinciExponent = 0.5
+	// colonizeExponent is a bit steeper for the many small steps of [Canvas.GenerateColonize]
+	// so twigs end up thin.
+	colonizeExponent = 0.75
+)
+
+// assignPipeWidths sets branch widths from the tips back to the root (pipe model):
+// a branch is as wide as needed to carry all the tips above it, the root(s) get rootWidth.
+// Depths are derived the same way so tips are at [Canvas.MaxDepth] (where leaves go) and
+// the root at 0.
+// parents[i] is the index of the parent of c.Branches[i] (-1 for roots), it is also set as
+// [Branch.Parent], and parents must come before their children. A branch carrying a fraction
+// r of the tips gets rootWidth*r^exponent.
+func (c *Canvas) assignPipeWidths(parents []int, rootWidth, exponent float64) {
+	n := len(c.Branches)
+	tips := make([]float64, n)
+	hasChild := make([]bool, n)
+	for _, p := range parents {
+		if p >= 0 {
+			hasChild[p] = true
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		if !hasChild[i] {
+			tips[i]++
+		}
+		if p := parents[i]; p >= 0 {
+			tips[p] += tips[i]
+		}
+	}
+	maxTips := 1.0
+	for i, p := range parents {
+		if p < 0 {
+			maxTips = max(maxTips, tips[i])
+		}
+	}
+	for i, b := range c.Branches {
+		b.Parent = parents[i]
+		b.StartWidth = max(1.0, rootWidth*math.Pow(tips[i]/maxTips, exponent))
+		b.EndWidth = 0
+	}
+	// End width matches the widest child start so chained segments join without steps.
+	for i, b := range c.Branches {
+		if p := parents[i]; p >= 0 {
+			c.Branches[p].EndWidth = max(c.Branches[p].EndWidth, b.StartWidth)
+		}
+	}
+	for i, b := range c.Branches {
+		if !hasChild[i] {
+			b.EndWidth = max(1.0, 0.7*b.StartWidth)
+		}
+		if parents[i] < 0 {
+			b.Depth = 0 // only roots get the flat bottom trunk treatment
+			continue
+		}
+		b.Depth = c.MaxDepth
+		if maxTips > 1 {
+			b.Depth = max(1, int(math.Round(float64(c.MaxDepth)*(1-math.Log(tips[i])/math.Log(maxTips)))))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ptree
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAssignPipeWidths(t *testing.T) {
+	// Trunk (0) forking into a tip (1) and a branch (2) forking again into 2 tips (3, 4).
+	parents := []int{-1, 0, 0, 2, 2}
+	tests := []struct {
+		name     string
+		exponent float64
+	}{
+		{"da Vinci", daVinciExponent},
+		{"colonize", colonizeExponent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Canvas{MaxDepth: 5}
+			for range parents {
+				c.Branches = append(c.Branches, &Branch{})
+			}
+			const rootWidth = 90.
+			c.assignPipeWidths(parents, rootWidth, tt.exponent)
+			// Fraction of the 3 tips carried by each branch.
+			ratios := []float64{1, 1. / 3, 2. / 3, 1. / 3, 1. / 3}
+			for i, b := range c.Branches {
+				want := rootWidth * math.Pow(ratios[i], tt.exponent)
+				if math.Abs(b.StartWidth-want) > 1e-9 {
+					t.Errorf("branch %d start width = %g, want %g", i, b.StartWidth, want)
+				}
+				if b.Parent != parents[i] {
+					t.Errorf("branch %d parent = %d, want %d", i, b.Parent, parents[i])
+				}
+			}
+			// Ends match the widest child, tips taper.
+			if c.Branches[0].EndWidth != c.Branches[2].StartWidth {
+				t.Errorf("trunk end width = %g, want the widest child start %g", c.Branches[0].EndWidth, c.Branches[2].StartWidth)
+			}
+			if want := 0.7 * c.Branches[1].StartWidth; math.Abs(c.Branches[1].EndWidth-want) > 1e-9 {
+				t.Errorf("tip end width = %g, want %g", c.Branches[1].EndWidth, want)
+			}
+			if c.Branches[0].Depth != 0 || c.Branches[3].Depth != c.MaxDepth {
+				t.Errorf("depths = %d (root) and %d (tip), want 0 and %d", c.Branches[0].Depth, c.Branches[3].Depth, c.MaxDepth)
+			}
+		})
+	}
+	// The da Vinci rule conserves the cross section area at each fork.
+	c := &Canvas{MaxDepth: 5}
+	for range parents {
+		c.Branches = append(c.Branches, &Branch{})
+	}
+	c.assignPipeWidths(parents, 90, daVinciExponent)
+	area := func(i int) float64 { return c.Branches[i].StartWidth * c.Branches[i].StartWidth }
+	if got, want := area(1)+area(2), area(0); math.Abs(got-want) > 1e-6 {
+		t.Errorf("children area %g, want the parent's %g", got, want)
+	}
+}
@@ -9,18 +9,77 @@ import (
 )
 
 type Canvas struct {
-	Width, Height  int
-	Branches       []*Branch
-	TrunkColor     tcolor.RGBColor // Base color for trunk (depth 0)
-	Rainbow        bool            // If true, use random colors per branch
-	Leaves         bool            // If true, render leaves at branch endpoints
-	LeafSize       float64         // Multiplier for leaf size
-	LeafDensity    int             // Number of leaves per branch (0 = auto based on resolution)
-	MaxDepth       int             // Maximum depth level for color calculations
-	Rand           rand.Rand
-	Spread         float64 // Multiplier for branch angles (1.0 = default)
-	TrunkWidthPct  float64 // Trunk width as percentage of canvas width
-	TrunkHeightPct float64 // Trunk height as percentage of canvas height
+	Width, Height   int
+	Branches        []*Branch
+	TrunkColor      tcolor.RGBColor // Base color for trunk (depth 0)
+	Rainbow         bool            // If true, use random colors per branch
+	Leaves          bool            // If true, render leaves at branch endpoints
+	LeafSize        float64         // Multiplier for leaf size
+	LeafShape       LeafShape       // Shape of the leaves (see [Species.LeafShape] for the species one)
+	LeafDensity     int             // Number of leaves per branch (0 = auto based on resolution)
+	MaxDepth        int             // Maximum depth level for color calculations
+	Rand            rand.Rand
+	Spread          float64         // Multiplier for branch angles (1.0 = default)
+	TrunkWidthPct   float64         // Trunk width as percentage of canvas width
+	TrunkHeightPct  float64         // Trunk height as percentage of canvas height
+	LSystem         *LSystem        // If set, generate the tree from this L-system grammar instead of the default branching
+	Algorithm       Algorithm       // Generation algorithm (when LSystem isn't set)
+	Attractors      int             // Number of attraction points for the Colonize algorithm
+	KillDistancePct float64         // Colonize: distance at which attraction points are reached, as percentage of canvas width
+	CrownShape      CrownShape      // Colonize: envelope of the attraction points
+	Species         *Species        // Branching and leaf parameters (nil = DefaultSpecies)
+	Gravity         float64         // How much branches bend down, more so with depth and length (0 = none)
+	LightDirection  Point           // Toward the sun (X right, Y up, unit length), for Phototropism and Lighting
+	Phototropism    float64         // How much branches bend toward the LightDirection (0 = none)
+	Curves          bool            // If true, branches are smooth Bezier curves instead of straight segments
+	Wind            *Wind           // If set, branches sway and leaves flutter according to Time
+	Time            float64         // Animation time in seconds
+	Foliage         []Leaf          // Leaves, generated on first draw of a tree (for a given resolution)
+	Growing         bool            // If true, draw the tree partially grown according to Progress
+	Progress        float64         // Growth animation progress from 0 (nothing) to 1 (fully grown)
+	Roots           int             // Number of surface roots spreading from the trunk base (0 = none)
+	Season          Season          // Foliage (and blossoms) of the given season
+	Snow            bool            // If true, snow lies on the branches in [Winter]
+	Snowfall        *Snowfall       // If set, the snow falls and accumulates over time (see [Canvas.StepFalling])
+	Flowers         bool            // If true, render flowers at the tip of the terminal branches
+	FlowerDensity   int             // Number of flowers per terminal branch (0 = auto based on resolution)
+	FlowerSize      float64         // Multiplier for flower size
+	FlowerColor     tcolor.RGBColor // Petals color (zero value = [DefaultFlowerColor])
+	Blooms          []Flower        // Flowers, generated on first draw of a tree (for a given resolution)
+	Fruit           *FruitKind      // If set, fruits of this kind hang from the branches
+	FruitDensity    float64         // Fraction (0 to 1) of the terminal and near terminal branches with a fruit
+	Fruits          []Fruit         // Fruits, generated on first draw of a tree (for a given resolution)
+	Fall            *LeafFall       // If set, leaves detach and fall over time (see [Canvas.StepFalling])
+	Pot             *Rim            // If set, the falling leaves and snow pile up on this pot rim below the image
+	Lighting        float64         // Strength of the lighting from the LightDirection (0 = flat colors to 1 = full)
+	Bark            BarkStyle       // Procedural bark texture of the (wide enough) branches at high resolution
+	// Rendering cache (random colors and foliage) so animated redraws of a tree are stable.
+	branchColors []tcolor.RGBColor
+	foliageWidth int
+	leafScale    float64
+	flowerWidth  int
+	flowerScale  float64
+	fruitWidth   int
+	fruitScale   float64
+	crown        Point          // Center of the tree bounding box
+	crownRadius  float64        // and half its largest dimension
+	shades       []*branchShade // Shading of each branch (see [Canvas.branchSource])
+	shadeWidth   int
+	swayBuf      []Branch
+	swayed       []*Branch
+	levels       []int // growth level of each branch
+	maxLevel     int
+	growBuf      []Branch
+	grown        []*Branch
+}
+
+// Leaf is a single leaf attached along a branch.
+type Leaf struct {
+	Branch        int     // Index in [Canvas.Branches]
+	T             float64 // Position along the branch (0 = start, 1 = end)
+	Angle         float64 // Orientation in radians
+	Color         tcolor.RGBColor
+	SizeVariation float64 // Size multiplier (around 1)
 }
 
 type Point struct {
@@ -35,16 +94,39 @@ type Branch struct {
 	StartWidth float64
 	EndWidth   float64
 	Rand       rand.Rand
-	Depth      int     // Current depth level (0 = trunk)
-	Spread     float64 // Angle spread multiplier
+	Depth      int      // Current depth level (0 = trunk)
+	Spread     float64  // Angle spread multiplier
+	Species    *Species // Branching parameters (nil = DefaultSpecies)
+	Gravity    float64  // Copied from [Canvas.Gravity]
+	Light      Point    // Toward the sun, its length is the phototropism strength (see [Canvas.phototropism])
+	Parent     int      // Index of the parent in [Canvas.Branches] (-1 for the trunk/roots)
+	Root       bool     // Surface root, see [Canvas.GenerateRoots]
+	// Optional cubic Bezier control points (when Curved is true), see [Branch.SetCurve].
+	Curved       bool
+	Ctrl1, Ctrl2 Point
 }
 
-func (c *Canvas) Generate() {
-	c.Branches = c.Branches[:0] // Reset branches for new tree generation, but keep slice allocated (if it has been already)
-	trunk := c.Trunk(c.TrunkWidthPct, c.TrunkHeightPct)
-	c.Branches = append(c.Branches, trunk)
-	// Generate branches breadth-first
-	c.GenerateBranchesBFS(trunk, c.MaxDepth)
+// Generate generates a new tree with the current settings. It only fails for an invalid
+// [Canvas.LSystem] grammar (which can be checked upfront with [LSystem.Validate]).
+func (c *Canvas) Generate() error {
+	c.resetDrawCache()
+	switch {
+	case c.LSystem != nil:
+		if err := c.GenerateLSystem(c.LSystem); err != nil {
+			return err
+		}
+	case c.Algorithm == Colonize:
+		c.GenerateColonize()
+	default:
+		c.Branches = c.Branches[:0] // Reset branches for new tree generation, but keep slice allocated (if it has been already)
+		trunk := c.Trunk(c.TrunkWidthPct, c.TrunkHeightPct)
+		c.Branches = append(c.Branches, trunk)
+		// Generate branches breadth-first
+		c.GenerateBranchesBFS(trunk, c.MaxDepth)
+	}
+	// Roots last so the tree for a given seed is the same with or without them.
+	c.GenerateRoots()
+	return nil
 }
 
 func (c *Canvas) Trunk(trunkWidthPct, trunkHeightPct float64) *Branch {
@@ -58,8 +140,18 @@ func (c *Canvas) Trunk(trunkWidthPct, trunkHeightPct float64) *Branch {
 		Rand:       c.Rand,
 		Depth:      0,
 		Spread:     c.Spread,
+		Species:    c.Species,
+		Gravity:    c.Gravity,
+		Light:      c.phototropism(),
+		Parent:     -1,
 	}
 	trunk.SetEnd()
+	if c.Curves {
+		// Lean the base the other way from the top for a more natural looking trunk, without
+		// drawing from c.Rand so the tree for a given seed is the same with or without curves.
+		lean := math.Pi/2 - 3*(trunk.Angle-math.Pi/2)
+		trunk.SetCurve(math.Cos(lean), -math.Sin(lean))
+	}
 	return trunk
 }
 
@@ -111,13 +203,15 @@ const (
 )
 
 // GenerateBranchesBFS generates branches in breadth-first order so branches
-// at the same depth level are added together.
+// at the same depth level are added together. root is expected to be the last
+// branch already in c.Branches.
 func (c *Canvas) GenerateBranchesBFS(root *Branch, maxDepth int) {
 	type queueItem struct {
 		branch *Branch
+		index  int // in c.Branches
 		depth  int
 	}
-	queue := []queueItem{{branch: root, depth: maxDepth}}
+	queue := []queueItem{{branch: root, index: len(c.Branches) - 1, depth: maxDepth}}
 
 	for len(queue) > 0 {
 		item := queue[0]
@@ -147,8 +241,9 @@ func (c *Canvas) GenerateBranchesBFS(root *Branch, maxDepth int) {
 
 		// Add all children of this branch to Branches slice (same depth together)
 		for _, child := range children {
+			child.Parent = item.index
 			c.Branches = append(c.Branches, child)
-			queue = append(queue, queueItem{branch: child, depth: nextDepth})
+			queue = append(queue, queueItem{branch: child, index: len(c.Branches) - 1, depth: nextDepth})
 		}
 	}
 }
@@ -164,37 +259,50 @@ func (b *Branch) Add(t BranchType, depth int) *Branch {
 		dist = b.Length * (0.3 + 0.3*b.Rand.Float64()) // Random point along branch for mid
 	}
 	dirX, dirY := b.Direction()
+	sp := b.species()
 	newB := &Branch{
 		Start:      Point{X: b.Start.X + dist*dirX, Y: b.Start.Y + dist*dirY},
 		Angle:      b.calculateChildAngle(t),
-		Length:     b.Length * (0.4 + 0.5*b.Rand.Float64()),
-		StartWidth: b.EndWidth * (0.6 + 0.1*b.Rand.Float64()),
+		Length:     b.Length * (sp.LengthMin + sp.LengthVar*b.Rand.Float64()),
+		StartWidth: b.EndWidth * (sp.WidthMin + sp.WidthVar*b.Rand.Float64()),
 		Rand:       b.Rand,
 		Depth:      depth,
 		Spread:     b.Spread,
+		Species:    b.Species,
+		Gravity:    b.Gravity,
+		Light:      b.Light,
 	}
-	newB.EndWidth = newB.StartWidth * (0.7 + 0.1*b.Rand.Float64())
+	newB.EndWidth = newB.StartWidth * (sp.TaperMin + sp.TaperVar*b.Rand.Float64())
+	// Gravity and light bend deeper and (relatively) longer branches more.
+	newB.Angle = bend(newB.Angle, b.Gravity, b.Light, 0.25*float64(depth)*newB.Length/b.Length)
 	// Adjust start point for terminal branches to make edges contiguous
 	if t != MidBranch {
 		newB.AdjustStartForParent(b, t)
+	} else if b.Curved {
+		newB.Start = b.PointAt(dist / b.Length) // on the curve rather than the chord
 	}
 	newB.SetEnd()
+	if b.Curved {
+		// Leave the parent along its tangent for a smooth joint.
+		newB.SetCurve(b.TangentAt(dist / b.Length))
+	}
 	return newB
 }
 
 func (b *Branch) calculateChildAngle(t BranchType) float64 {
+	sp := b.species()
 	// Scale wiggle with spread for more natural variation
 	wiggle := (b.Rand.Float64() - 0.5) * math.Pi / 20 * b.Spread
 	switch t {
 	case LeftBranch:
-		return b.Angle - math.Pi/6*b.Spread + wiggle
+		return b.Angle - sp.BranchAngle*b.Spread + wiggle
 	case RightBranch:
-		return b.Angle + math.Pi/6*b.Spread + wiggle
+		return b.Angle + sp.BranchAngle*b.Spread + wiggle
 	default: // MidBranch
 		sign := 1.0
 		if b.Rand.Float64() < 0.5 {
 			sign = -1.0
 		}
-		return b.Angle + sign*math.Pi/8*b.Spread + wiggle
+		return b.Angle + sign*sp.MidAngle*b.Spread + wiggle
 	}
 }
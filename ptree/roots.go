@@ -0,0 +1,48 @@
+package ptree
+
+import "math"
+
+// GenerateRoots adds [Canvas.Roots] surface roots (nebari) to [Canvas.Branches]: they leave the
+// trunk (first branch) a bit above its base going steeply down and outward, so the trunk flares
+// into them, then spread sideways alternating left and right and taper into the ground.
+// The front most roots (added last) are shorter as seen in perspective.
+func (c *Canvas) GenerateRoots() {
+	if c.Roots <= 0 || len(c.Branches) == 0 {
+		return
+	}
+	trunk := c.Branches[0]
+	base := trunk.Start
+	w := trunk.StartWidth
+	for i := range c.Roots {
+		sign := 1.0
+		if i%2 == 1 {
+			sign = -1.0
+		}
+		length := w * (1.0 + 1.2*c.Rand.Float64()) / (1 + 0.5*float64(i/2))
+		start := Point{X: base.X + sign*w*0.15, Y: base.Y - w*(0.35+0.15*c.Rand.Float64())}
+		end := Point{X: base.X + sign*(w*0.5+length), Y: base.Y - w*0.05*c.Rand.Float64()}
+		dx, dy := end.X-start.X, end.Y-start.Y
+		root := &Branch{
+			Start:      start,
+			End:        end,
+			Angle:      math.Atan2(-dy, dx),
+			Length:     math.Hypot(dx, dy),
+			StartWidth: w * (0.45 + 0.15*c.Rand.Float64()),
+			Rand:       c.Rand,
+			Depth:      1, // trunk color but not the flat bottom of depth 0
+			Spread:     c.Spread,
+			Species:    c.Species,
+			Parent:     -1,
+			Root:       true,
+			Curved:     true,
+		}
+		root.EndWidth = root.StartWidth * (0.1 + 0.1*c.Rand.Float64())
+		// Steep out of the trunk, flattening along the ground.
+		third := root.Length / 3
+		startDirX, startDirY := sign*0.35, 0.94
+		endDirX, endDirY := sign*0.995, 0.1
+		root.Ctrl1 = Point{X: start.X + startDirX*third, Y: start.Y + startDirY*third}
+		root.Ctrl2 = Point{X: end.X - endDirX*third, Y: end.Y - endDirY*third}
+		c.Branches = append(c.Branches, root)
+	}
+}
@@ -0,0 +1,186 @@
+package ptree
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"fortio.org/terminal/ansipixels"
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// Season changes the foliage: the leaves generated for a tree are the summer ones and the
+// other seasons show some of them, in different colors, or none (and spring blossoms).
+type Season int
+
+const (
+	Summer Season = iota // Full green foliage (default)
+	Spring               // Sparse light green leaves and pink blossoms
+	Autumn               // Red, orange and yellow leaves, some already fallen
+	Winter               // Bare branches, optionally with snow (see [Canvas.Snow])
+)
+
+var seasonNames = []string{"summer", "spring", "autumn", "winter"}
+
+func (s Season) String() string {
+	if s < 0 || int(s) >= len(seasonNames) {
+		return fmt.Sprintf("Season(%d)", int(s))
+	}
+	return seasonNames[s]
+}
+
+// ParseSeason returns the season for the given name (spring, summer, autumn or winter).
+func ParseSeason(name string) (Season, error) {
+	for i, n := range seasonNames {
+		if n == name {
+			return Season(i), nil
+		}
+	}
+	return Summer, fmt.Errorf("unknown season %q, should be one of %v", name, seasonNames)
+}
+
+// SeasonAt returns the season at the given fraction of the year (0 being the start of spring),
+// to cycle through the year.
+func SeasonAt(year float64) Season {
+	year -= math.Floor(year)
+	return [...]Season{Spring, Summer, Autumn, Winter}[min(3, int(year*4))]
+}
+
+// Fraction of the leaves that are out in spring and still there in autumn, and fraction
+// replaced by blossoms in spring.
+const (
+	springLeaves   = 0.45
+	springBlossoms = 0.35
+	autumnLeaves   = 0.7
+)
+
+// snowMinDepth is the snow depth (in pixels) on the thinnest horizontal branches.
+const snowMinDepth = 1.5
+
+var snowColor = tcolor.RGBColor{R: 240, G: 246, B: 255}
+
+// leafRandom returns a stable pseudo random number in [0, 1) for leaf i (k selecting
+// independent values) without consuming the tree Rand, so the tree and its leaves are the
+// same in all seasons.
+func leafRandom(i, k int) float64 {
+	v := math.Sin(float64(i)*12.9898+float64(k)*78.233) * 43758.5453
+	return v - math.Floor(v)
+}
+
+// lerpColor returns the color between a (f = 0) and b (f = 1).
+func lerpColor(a, b tcolor.RGBColor, f float64) tcolor.RGBColor {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f))
+	}
+	return tcolor.RGBColor{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B)}
+}
+
+// seasonLeaf returns the color of leaf i in the current season, and false when the leaf isn't
+// there: leaves are sparse in spring (some replaced by blossoms, see [Canvas.blossom]), some
+// have fallen in autumn and they are all gone in winter.
+func (c *Canvas) seasonLeaf(i int) (tcolor.RGBColor, bool) {
+	leaf := &c.Foliage[i]
+	switch c.Season {
+	case Spring:
+		if leafRandom(i, 0) >= springLeaves {
+			return tcolor.RGBColor{}, false
+		}
+		// Young leaves: lighter and more yellow.
+		return lerpColor(leaf.Color, tcolor.RGBColor{R: 230, G: 255, B: 180}, 0.45), true
+	case Autumn:
+		if leafRandom(i, 0) >= autumnLeaves {
+			return tcolor.RGBColor{}, false
+		}
+		hue := 0.06 + 0.22*leafRandom(i, 1) // red to orange to yellow
+		clr := tcolor.Oklchf(0.55+0.2*leafRandom(i, 2), 0.7, hue)
+		ct, data := clr.Decode()
+		return tcolor.ToRGB(ct, data), true
+	case Winter:
+		return tcolor.RGBColor{}, false
+	default:
+		return leaf.Color, true
+	}
+}
+
+// blossom returns the color of the blossom (a flower, see [drawFlower]) at leaf i position,
+// false if there is none (only in spring, where leaves are missing).
+func (c *Canvas) blossom(i int) (tcolor.RGBColor, bool) {
+	if c.Season != Spring {
+		return tcolor.RGBColor{}, false
+	}
+	if r := leafRandom(i, 0); r < springLeaves || r >= springLeaves+springBlossoms {
+		return tcolor.RGBColor{}, false
+	}
+	return lerpColor(c.flowerColor(), tcolor.RGBColor{R: 255, G: 255, B: 255}, 0.5*leafRandom(i, 3)), true
+}
+
+// snowCap returns the outline of the snow lying on top of a branch: deeper on wide and
+// horizontal parts, none on vertical ones, scaled by cover (1 for a full snow cover, see
+// [Canvas.snowCover]). nil when there is no visible snow on the branch.
+// The part of the branch inside its parent (nil for the trunk and roots) is skipped.
+func (b *Branch) snowCap(parent *Branch, cover float64) []float64 {
+	n := 1
+	if b.Curved {
+		n = b.curveSamples()
+	}
+	t0 := 0.0
+	if parent != nil && b.Length > 0 {
+		// Distance to get out of the parent at this angle (mid branches start on its axis).
+		out := parent.EndWidth/2/max(0.3, math.Abs(math.Sin(b.Angle-parent.Angle))) + 0.6*b.StartWidth
+		t0 = min(0.5, out/b.Length)
+	}
+	points := make([]float64, 4*(n+1))
+	snow := false
+	for i := 0; i <= n; i++ {
+		t := t0 + (1-t0)*float64(i)/float64(n)
+		p := b.PointAt(t)
+		dirX, dirY := b.TangentAt(t)
+		// Perpendicular pointing up (negative Y).
+		upX, upY := dirY, -dirX
+		if upY > 0 {
+			upX, upY = -upX, -upY
+		}
+		halfWidth := (b.StartWidth + (b.EndWidth-b.StartWidth)*t) / 2
+		flat := upY * upY // upY is -1 for horizontal parts
+		depth := cover * flat * (0.8*halfWidth + snowMinDepth)
+		snow = snow || depth > 0.2
+		// Mostly on the branch, a bit above its edge.
+		outer, inner := halfWidth+0.4*depth, halfWidth-0.6*depth
+		points[2*i] = p.X + upX*outer
+		points[2*i+1] = p.Y + upY*outer
+		j := 2 * (2*n + 1 - i)
+		points[j] = p.X + upX*inner
+		points[j+1] = p.Y + upY*inner
+	}
+	if !snow {
+		return nil
+	}
+	return points
+}
+
+// parentOf returns the parent of b in branches, nil for the trunk and roots.
+func parentOf(branches []*Branch, b *Branch) *Branch {
+	if b.Parent < 0 {
+		return nil
+	}
+	return branches[b.Parent]
+}
+
+// drawSnow renders the snow cap of a branch.
+func drawSnow(img draw.Image, b, parent *Branch, cover float64, useLines bool) {
+	points := b.snowCap(parent, cover)
+	if points == nil {
+		return
+	}
+	if !useLines {
+		fillPolygon(img.(*image.RGBA), points, snowColor)
+		return
+	}
+	// Line along the top of the cap.
+	nimg := img.(*image.NRGBA)
+	half := len(points) / 2
+	for i := 0; i+3 < half; i += 2 {
+		ansipixels.DrawAALine(nimg, points[i], points[i+1], points[i+2], points[i+3], toNRGBA(snowColor))
+	}
+}
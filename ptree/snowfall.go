@@ -0,0 +1,217 @@
+package ptree
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"fortio.org/rand"
+	"fortio.org/terminal/ansipixels"
+)
+
+// Snowfall makes snow flakes fall (in [Winter], when [Canvas.Snow] is set) and accumulate over
+// time: the snow caps on the branches get deeper and a snow layer builds up at the bottom of
+// the image, or on the [Canvas.Pot] rim (the other flakes falling out of view). Without one the
+// caps are drawn fully covered right away. It is advanced by [Canvas.StepFalling].
+type Snowfall struct {
+	Rate    float64 // Flakes per second for each 100 pixels of image width
+	Max     int     // Maximum number of flakes in the air
+	rnd     rand.Rand
+	time    float64
+	pending float64 // Fractional flakes to add
+	cover   float64 // Snow caps depth from 0 (none yet) to 1 (full)
+	flakes  []flake
+	ground  []float64 // Depth of the snow layer per image column
+	first   int       // First and last columns of the snow layer (see [Canvas.groundColumns])
+	last    int
+	tops    []float64 // Top of the highest snow cap per image column (+Inf if none)
+}
+
+type flake struct {
+	Pos   Point
+	Size  float64 // Radius in pixels
+	Phase float64
+	Speed float64 // Fall speed multiplier (around 1)
+	Catch bool    // Lands on the branches if any below (others go through the gaps)
+}
+
+const (
+	// snowCatch is the fraction of the flakes falling on a branch that stay there.
+	snowCatch = 0.4
+	// snowCoverFlakes is how many caught flakes, per image column, fully cover the branches.
+	snowCoverFlakes = 0.6
+	// maxSnowSlope is the maximum difference of depth between 2 columns of the snow layer.
+	maxSnowSlope = 0.15
+)
+
+// NewSnowfall creates a snowfall of rate flakes per second per 100 pixels of width with at
+// most maxFlakes in the air. The flakes are random, fully determined by rnd.
+func NewSnowfall(rate float64, maxFlakes int, rnd rand.Rand) *Snowfall {
+	return &Snowfall{Rate: rate, Max: maxFlakes, rnd: rnd}
+}
+
+// reset removes all the snow (for a new tree or resolution).
+func (s *Snowfall) reset() {
+	s.pending = 0
+	s.cover = 0
+	s.flakes = s.flakes[:0]
+	s.ground = s.ground[:0]
+	s.tops = s.tops[:0]
+}
+
+// snowCover returns how deep the snow caps are (see [Branch.snowCap]).
+func (c *Canvas) snowCover() float64 {
+	if c.Snowfall == nil {
+		return 1
+	}
+	return c.Snowfall.cover
+}
+
+// snowTops computes the top of the snow caps (fully covered) in each image column, where the
+// flakes land on the branches.
+func (s *Snowfall) snowTops(c *Canvas) {
+	s.tops = s.tops[:0]
+	for range c.Width {
+		s.tops = append(s.tops, math.Inf(1))
+	}
+	for _, b := range c.Branches {
+		points := b.snowCap(parentOf(c.Branches, b), 1)
+		// The first half of the points is the outer edge of the cap.
+		for i := 0; i+3 < len(points)/2; i += 2 {
+			x0, y0, x1, y1 := points[i], points[i+1], points[i+2], points[i+3]
+			if x0 > x1 {
+				x0, y0, x1, y1 = x1, y1, x0, y0
+			}
+			for x := max(0, int(math.Ceil(x0))); x <= min(c.Width-1, int(x1)); x++ {
+				y := y0
+				if x1 > x0 {
+					y += (y1 - y0) * (float64(x) - x0) / (x1 - x0)
+				}
+				s.tops[x] = min(s.tops[x], y)
+			}
+		}
+	}
+}
+
+// column returns the snow layer column for x, -1 when outside of the layer (or the image).
+func (s *Snowfall) column(x float64) int {
+	i := int(x)
+	if x < 0 || i < s.first || i > s.last || i >= len(s.ground) {
+		return -1
+	}
+	return i
+}
+
+// settle adds depth of snow in column x, sliding down to the sides where it's too steep, up
+// to maxDepth.
+func (s *Snowfall) settle(x int, depth, maxDepth float64) {
+	// Small amounts at a time so it spreads even on flat ground.
+	for ; depth > 0; depth -= maxSnowSlope {
+		i := x
+		for {
+			// Go down to the lowest neighbor as long as it's too steep.
+			lowest := i
+			for _, j := range [...]int{i - 1, i + 1} {
+				if j >= s.first && j <= s.last && s.ground[j] < s.ground[lowest] {
+					lowest = j
+				}
+			}
+			if lowest == i || s.ground[i]+min(depth, maxSnowSlope) <= s.ground[lowest]+maxSnowSlope {
+				break
+			}
+			i = lowest
+		}
+		s.ground[i] = min(maxDepth, s.ground[i]+min(depth, maxSnowSlope))
+	}
+}
+
+// stepSnow advances the snowfall: new flakes appear at the top, the ones in the air move down
+// and land on the branches (deepening the caps) or the ground. The snow melts away outside of
+// winter.
+func (c *Canvas) stepSnow(dt float64) {
+	s := c.Snowfall
+	if !c.Snow || c.Season != Winter || c.Growing {
+		s.reset()
+		return
+	}
+	s.time += dt
+	if len(s.ground) != c.Width {
+		s.ground = append(s.ground[:0], make([]float64, c.Width)...)
+	}
+	if len(s.tops) != c.Width {
+		s.snowTops(c)
+	}
+	s.first, s.last = c.groundColumns()
+	height := float64(c.Height)
+	// Relative to the image height so the fall takes about the same time at all resolutions.
+	speed := 0.12 * height
+	push := 0.0
+	if c.Wind != nil {
+		push = 0.15 * height * c.Wind.Force(c.Time)
+	}
+	kept := s.flakes[:0]
+	for _, f := range s.flakes {
+		f.Pos.X += (push + 0.1*speed*math.Sin(2*s.time+f.Phase)) * dt
+		f.Pos.Y += speed * f.Speed * dt
+		col, x := int(f.Pos.X), s.column(f.Pos.X)
+		switch {
+		case f.Catch && f.Pos.X >= 0 && col < len(s.tops) && f.Pos.Y >= s.tops[col] &&
+			f.Pos.Y < s.tops[col]+f.Size+speed*dt:
+			s.cover = min(1, s.cover+1/(snowCoverFlakes*float64(c.Width)))
+		case x >= 0 && f.Pos.Y >= height-1-s.ground[x]:
+			s.settle(x, 0.02*height, height/8)
+		case f.Pos.Y < height+f.Size:
+			// Still in the air, or outside of the image (may come back in with the wind) or
+			// falling past the pot.
+			kept = append(kept, f)
+		}
+	}
+	s.flakes = kept
+	width := float64(c.Width)
+	s.pending += s.Rate * width / 100 * dt
+	scale := resolutionScale(c.Width)
+	for ; s.pending >= 1; s.pending-- {
+		if len(s.flakes) >= s.Max {
+			s.pending = 0
+			break
+		}
+		s.flakes = append(s.flakes, flake{
+			// Wider than the image so the wind doesn't leave the left side empty.
+			Pos:   Point{X: (1.4*s.rnd.Float64() - 0.4) * width, Y: -2},
+			Size:  scale * (0.6 + 0.8*s.rnd.Float64()),
+			Phase: 2 * math.Pi * s.rnd.Float64(),
+			Speed: 0.7 + 0.6*s.rnd.Float64(),
+			Catch: s.rnd.Float64() < snowCatch,
+		})
+	}
+}
+
+// drawSnowfall renders the snow layer at the bottom of the image and the flakes in the air.
+func drawSnowfall(img draw.Image, c *Canvas, useLines bool) {
+	s := c.Snowfall
+	height := float64(c.Height)
+	if len(s.ground) > 0 {
+		if useLines {
+			nimg := img.(*image.NRGBA)
+			for x := 1; x < len(s.ground); x++ {
+				ansipixels.DrawAALine(nimg, float64(x)-0.5, height-s.ground[x-1], float64(x)+0.5, height-s.ground[x],
+					toNRGBA(snowColor))
+			}
+		} else {
+			points := make([]float64, 0, 2*len(s.ground)+4)
+			points = append(points, 0, height)
+			for x, d := range s.ground {
+				points = append(points, float64(x)+0.5, height-d)
+			}
+			points = append(points, float64(len(s.ground)), height)
+			fillPolygon(img.(*image.RGBA), points, snowColor)
+		}
+	}
+	for _, f := range s.flakes {
+		if useLines || f.Size < 1.5 {
+			img.Set(int(f.Pos.X), int(f.Pos.Y), toRGBA(snowColor))
+			continue
+		}
+		fillPolygon(img.(*image.RGBA), circle(f.Pos.X, f.Pos.Y, f.Size), snowColor)
+	}
+}
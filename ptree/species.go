@@ -0,0 +1,208 @@
+package ptree
+
+import (
+	"fmt"
+	"math"
+)
+
+// Species is a named preset of generation and rendering parameters.
+// Ratios are applied as Min + Var*random (so a Var of 0 means no randomness).
+type Species struct {
+	Name        string
+	Description string
+	// Values for the matching [Canvas] fields / command line flags (which can override them).
+	Depth          int
+	Spread         float64
+	TrunkWidthPct  float64
+	TrunkHeightPct float64
+	TrunkColor     string // hex color
+	LeafSize       float64
+	Droop          float64 // Default for [Canvas.Gravity] (0 = none, 1 = weeping)
+	// Branching angles in radians (left/right branches at the end, mid branch along the parent).
+	BranchAngle float64
+	MidAngle    float64
+	// Child length as ratio of the parent length.
+	LengthMin, LengthVar float64
+	// Child start width as ratio of the parent end width.
+	WidthMin, WidthVar float64
+	// End width as ratio of the start width.
+	TaperMin, TaperVar float64
+	// Leaf hue (OKLCH, 0-1 range, 0.33 is green) and shape.
+	LeafHue   float64
+	LeafShape LeafShape
+}
+
+// DefaultSpecies is the generic tree used when no species is selected.
+var DefaultSpecies = &Species{
+	Name:           "default",
+	Description:    "Generic bonsai",
+	Depth:          6,
+	Spread:         1.0,
+	TrunkWidthPct:  7.0,
+	TrunkHeightPct: 35.0,
+	LeafSize:       1.0,
+	BranchAngle:    math.Pi / 6,
+	MidAngle:       math.Pi / 8,
+	LengthMin:      0.4,
+	LengthVar:      0.5,
+	WidthMin:       0.6,
+	WidthVar:       0.1,
+	TaperMin:       0.7,
+	TaperVar:       0.1,
+	LeafHue:        0.33,
+}
+
+// SpeciesList is the registry of named presets (in listing order).
+var SpeciesList = []*Species{
+	DefaultSpecies,
+	{
+		Name:           "maple",
+		Description:    "Japanese maple: wide spreading crown, red foliage",
+		Depth:          7,
+		Spread:         1.2,
+		TrunkWidthPct:  6.0,
+		TrunkHeightPct: 30.0,
+		TrunkColor:     "#5A3A22",
+		LeafSize:       1.0,
+		BranchAngle:    math.Pi / 5,
+		MidAngle:       math.Pi / 7,
+		LengthMin:      0.45,
+		LengthVar:      0.4,
+		WidthMin:       0.6,
+		WidthVar:       0.1,
+		TaperMin:       0.7,
+		TaperVar:       0.1,
+		LeafHue:        0.05,
+		LeafShape:      LeafMaple,
+	},
+	{
+		Name:           "pine",
+		Description:    "Pine: tall trunk, short near horizontal branches, dark needles",
+		Depth:          6,
+		Spread:         0.8,
+		TrunkWidthPct:  5.0,
+		TrunkHeightPct: 45.0,
+		TrunkColor:     "#4A3020",
+		LeafSize:       0.8,
+		BranchAngle:    math.Pi / 3,
+		MidAngle:       math.Pi / 5,
+		LengthMin:      0.35,
+		LengthVar:      0.3,
+		WidthMin:       0.55,
+		WidthVar:       0.1,
+		TaperMin:       0.65,
+		TaperVar:       0.1,
+		Droop:          0.3,
+		LeafHue:        0.42,
+		LeafShape:      LeafPineFan,
+	},
+	{
+		Name:           "juniper",
+		Description:    "Juniper: short thick twisting trunk, blue green foliage",
+		Depth:          7,
+		Spread:         1.4,
+		TrunkWidthPct:  9.0,
+		TrunkHeightPct: 20.0,
+		TrunkColor:     "#6B4F3A",
+		LeafSize:       0.7,
+		BranchAngle:    math.Pi / 4,
+		MidAngle:       math.Pi / 6,
+		LengthMin:      0.5,
+		LengthVar:      0.4,
+		WidthMin:       0.65,
+		WidthVar:       0.1,
+		TaperMin:       0.7,
+		TaperVar:       0.15,
+		Droop:          0.2,
+		LeafHue:        0.45,
+		LeafShape:      LeafJuniper,
+	},
+	{
+		Name:           "willow",
+		Description:    "Weeping willow: long drooping branches, light narrow leaves",
+		Depth:          7,
+		Spread:         0.9,
+		TrunkWidthPct:  7.0,
+		TrunkHeightPct: 30.0,
+		TrunkColor:     "#5C4630",
+		LeafSize:       0.8,
+		BranchAngle:    math.Pi / 6,
+		MidAngle:       math.Pi / 8,
+		LengthMin:      0.5,
+		LengthVar:      0.4,
+		WidthMin:       0.6,
+		WidthVar:       0.1,
+		TaperMin:       0.7,
+		TaperVar:       0.1,
+		Droop:          1.5,
+		LeafHue:        0.30,
+		LeafShape:      LeafLanceolate,
+	},
+	{
+		Name:           "baobab",
+		Description:    "Baobab: massive trunk topped by short stubby branches",
+		Depth:          6,
+		Spread:         1.5,
+		TrunkWidthPct:  16.0,
+		TrunkHeightPct: 50.0,
+		TrunkColor:     "#8A7560",
+		LeafSize:       1.2,
+		BranchAngle:    math.Pi / 4,
+		MidAngle:       math.Pi / 6,
+		LengthMin:      0.35,
+		LengthVar:      0.25,
+		WidthMin:       0.55,
+		WidthVar:       0.1,
+		TaperMin:       0.6,
+		TaperVar:       0.1,
+		LeafHue:        0.33,
+	},
+	{
+		Name:           "cherry",
+		Description:    "Cherry: dark bark, open crown, pink foliage",
+		Depth:          6,
+		Spread:         1.3,
+		TrunkWidthPct:  6.0,
+		TrunkHeightPct: 30.0,
+		TrunkColor:     "#4B2E2A",
+		LeafSize:       1.0,
+		BranchAngle:    math.Pi / 5,
+		MidAngle:       math.Pi / 7,
+		LengthMin:      0.45,
+		LengthVar:      0.45,
+		WidthMin:       0.6,
+		WidthVar:       0.1,
+		TaperMin:       0.7,
+		TaperVar:       0.1,
+		Droop:          0.1,
+		LeafHue:        0.98,
+	},
+}
+
+// FindSpecies returns the species preset with the given name.
+func FindSpecies(name string) (*Species, error) {
+	names := make([]string, 0, len(SpeciesList))
+	for _, s := range SpeciesList {
+		if s.Name == name {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	return nil, fmt.Errorf("unknown species %q, should be one of %v", name, names)
+}
+
+// species returns the canvas species or the default one if not set.
+func (c *Canvas) species() *Species {
+	if c.Species == nil {
+		return DefaultSpecies
+	}
+	return c.Species
+}
+
+// species returns the branch species or the default one if not set.
+func (b *Branch) species() *Species {
+	if b.Species == nil {
+		return DefaultSpecies
+	}
+	return b.Species
+}
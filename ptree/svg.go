@@ -0,0 +1,256 @@
+package ptree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"fortio.org/terminal/ansipixels/tcolor"
+)
+
+// WriteSVG writes the tree as a resolution independent SVG image of [Canvas.Width] by
+// [Canvas.Height]: branches are filled tapered polygons (or 1 pixel wide strokes when useLines
+// is true, like [DrawTree]) and leaves filled triangles, with the same colors and random
+// choices as [DrawTree] so a given seed gives the same tree in both.
+func WriteSVG(w io.Writer, c *Canvas, useLines bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		c.Width, c.Height, c.Width, c.Height)
+	c.prepareDraw(c.Width)
+	branches, grown := c.frameBranches()
+	snow := c.Snow && c.Season == Winter
+	var buf []byte
+	for i, b := range grown {
+		if b == nil {
+			continue // not grown yet
+		}
+		rgb := c.branchColors[i]
+		if useLines {
+			buf = buf[:0]
+			n := b.curveSamples()
+			for s := 0; s <= n; s++ {
+				buf = appendPoint(buf, b.PointAt(float64(s)/float64(n)), s > 0)
+			}
+			fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1"/>`+"\n", buf,
+				svgColor(c.litBranch(b, rgb)))
+		} else {
+			// The fill (gradient def) must be written before the path using it.
+			fill := writeSVGBranchFill(bw, c, i, b, rgb)
+			fmt.Fprintf(bw, `<path d="%s" fill="%s"/>`+"\n", svgPath(buf[:0], b.outline()), fill)
+		}
+		if snow {
+			writeSVGSnow(bw, b, parentOf(branches, b), c.snowCover(), useLines)
+		}
+	}
+	if c.Leaves {
+		writeSVGLeaves(bw, c, branches, useLines)
+	}
+	if growth := c.leafGrowth(); c.Fruit != nil && growth > 0 && c.Season != Spring {
+		writeSVGFruits(bw, c, branches, growth, useLines)
+	}
+	if growth := c.leafGrowth(); c.Flowers && growth > 0 && c.Season != Winter {
+		for i := range c.Blooms {
+			f := &c.Blooms[i]
+			x, y, r := c.flowerPosition(f, branches, growth)
+			angle := f.Angle
+			if c.Wind != nil {
+				angle += c.Wind.flutter(c.Time, i)
+			}
+			writeSVGFlower(bw, x, y, r, angle, f.Color, useLines)
+		}
+	}
+	if c.Leaves && c.Fall != nil {
+		for _, leaves := range [...][]fallingLeaf{c.Fall.fallen, c.Fall.falling} {
+			for _, l := range leaves {
+				writeSVGLeaf(bw, l.Pos.X, l.Pos.Y, l.Angle, l.BranchWidth, l.Color, l.Size, c.LeafShape, useLines)
+			}
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// writeSVGBranchFill returns the fill of branch i of color rgb, the SVG equivalent of
+// branchSource: for [Canvas.Lighting] a gradient across the chord of the branch, written as a
+// def with the id "b" + i.
+func writeSVGBranchFill(bw *bufio.Writer, c *Canvas, i int, b *Branch, rgb tcolor.RGBColor) string {
+	if c.Lighting <= 0 {
+		return svgColor(rgb)
+	}
+	if c.Width < lowResLight {
+		return svgColor(c.litBranch(b, rgb))
+	}
+	lx, ly, lz := c.lightVector()
+	dx, dy := b.End.X-b.Start.X, b.End.Y-b.Start.Y
+	l := max(1e-9, math.Hypot(dx, dy))
+	px, py := -dy/l, dx/l
+	mid := b.PointAt(0.5)
+	half := max(0.5, (b.StartWidth+b.EndWidth)/4)
+	from, to := Point{X: mid.X - px*half, Y: mid.Y - py*half}, Point{X: mid.X + px*half, Y: mid.Y + py*half}
+	fmt.Fprintf(bw, `<defs><linearGradient id="b%d" gradientUnits="userSpaceOnUse" x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f">`,
+		i, from.X, from.Y, to.X, to.Y)
+	const stops = 8
+	for k := 0; k <= stops; k++ {
+		u := 2*float64(k)/stops - 1
+		fmt.Fprintf(bw, `<stop offset="%.3f" stop-color="%s"/>`, float64(k)/stops,
+			svgColor(c.shadeColor(rgb, cylinderLight(u, px*lx+py*ly, lz))))
+	}
+	bw.WriteString("</linearGradient></defs>\n")
+	return fmt.Sprintf("url(#b%d)", i)
+}
+
+// writeSVGLeaves is the SVG equivalent of drawLeaves.
+func writeSVGLeaves(bw *bufio.Writer, c *Canvas, branches []*Branch, useLines bool) {
+	growth := c.leafGrowth()
+	if growth <= 0 {
+		return
+	}
+	for i := range c.Foliage {
+		if !c.attached(i) {
+			continue // falling or fallen
+		}
+		leaf := &c.Foliage[i]
+		b := branches[leaf.Branch]
+		pos := b.PointAt(leaf.T)
+		size := growth * c.leafScale * leaf.SizeVariation
+		if rgb, ok := c.blossom(i); ok {
+			writeSVGFlower(bw, pos.X, pos.Y, flowerRadius(b.EndWidth, size), leaf.Angle, rgb, useLines)
+			continue
+		}
+		rgb, ok := c.seasonLeaf(i)
+		if !ok {
+			continue
+		}
+		angle := leaf.Angle
+		if c.Wind != nil {
+			angle += c.Wind.flutter(c.Time, i)
+		}
+		writeSVGLeaf(bw, pos.X, pos.Y, angle, b.EndWidth, c.litLeaf(i, pos, rgb), size, c.LeafShape, useLines)
+	}
+}
+
+// writeSVGLeaf is the SVG equivalent of drawLeaf.
+func writeSVGLeaf(
+	bw *bufio.Writer, x, y, angle, branchWidth float64, rgb tcolor.RGBColor, sizeMultiplier float64,
+	shape LeafShape, useLines bool,
+) {
+	var buf []byte
+	for _, points := range leafPolygons(x, y, angle, branchWidth, sizeMultiplier, shape) {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		if useLines && len(points) == 6 {
+			// Just one edge of the triangles, like drawLeaf.
+			buf = appendPoint(append(buf, 'M'), Point{X: points[0], Y: points[1]}, false)
+			buf = appendPoint(append(buf, " L"...), Point{X: points[2], Y: points[3]}, false)
+			continue
+		}
+		buf = svgPath(buf, points)
+	}
+	if useLines {
+		fmt.Fprintf(bw, `<path d="%s" fill="none" stroke="%s" stroke-width="1"/>`+"\n", buf, svgColor(rgb))
+		return
+	}
+	fmt.Fprintf(bw, `<path d="%s" fill="%s"/>`+"\n", buf, svgColor(rgb))
+}
+
+// writeSVGFlower is the SVG equivalent of drawFlower.
+func writeSVGFlower(bw *bufio.Writer, x, y, r, angle float64, rgb tcolor.RGBColor, useLines bool) {
+	var buf []byte
+	if useLines {
+		for k := range 5 {
+			if k > 0 {
+				buf = append(buf, ' ')
+			}
+			a := angle + float64(k)*2*math.Pi/5
+			buf = appendPoint(append(buf, 'M'), Point{X: x, Y: y}, false)
+			buf = appendPoint(append(buf, " L"...), Point{X: x + r*math.Cos(a), Y: y + r*math.Sin(a)}, false)
+		}
+		fmt.Fprintf(bw, `<path d="%s" fill="none" stroke="%s" stroke-width="1"/>`+"\n", buf, svgColor(rgb))
+		return
+	}
+	for _, petal := range flowerPetals(x, y, r, angle) {
+		buf = append(svgPath(buf, petal), ' ')
+	}
+	fmt.Fprintf(bw, `<path d="%s" fill="%s"/>`+"\n", buf[:len(buf)-1], svgColor(rgb))
+	fmt.Fprintf(bw, `<path d="%s" fill="%s"/>`+"\n", svgPath(buf[:0], circle(x, y, 0.22*r)), svgColor(flowerCenterColor))
+}
+
+// writeSVGFruits is the SVG equivalent of drawFruits, the shading being a radial gradient.
+func writeSVGFruits(bw *bufio.Writer, c *Canvas, branches []*Branch, growth float64, useLines bool) {
+	rgb := c.Fruit.Color
+	if !useLines {
+		// Same as radialShade, relative to the bounding box of each fruit.
+		h := c.fruitHighlight()
+		fmt.Fprintf(bw, `<defs><radialGradient id="fruit" cx="%s" cy="%s" r="0.675">`+
+			`<stop offset="0" stop-color="%s"/><stop offset="0.45" stop-color="%s"/><stop offset="1" stop-color="%s"/>`+
+			"</radialGradient></defs>\n",
+			strconv.FormatFloat(0.5+h.X/2, 'f', 3, 64), strconv.FormatFloat(0.5+h.Y/2, 'f', 3, 64),
+			svgColor(lerpColor(rgb, tcolor.RGBColor{R: 255, G: 255, B: 255}, 0.55)), svgColor(rgb),
+			svgColor(lerpColor(rgb, tcolor.RGBColor{}, 0.45)))
+	}
+	var buf []byte
+	for i := range c.Fruits {
+		stem, center, r := c.fruitGeometry(i, branches, growth)
+		top := Point{X: center.X + (stem.X-center.X)/1.4, Y: center.Y + (stem.Y-center.Y)/1.4}
+		buf = appendPoint(appendPoint(buf[:0], stem, false), top, true)
+		fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s"/>`+"\n",
+			buf, svgColor(stemColor), strconv.FormatFloat(max(1, 0.2*r), 'f', 2, 64))
+		cx, cy, cr := strconv.FormatFloat(center.X, 'f', 2, 64), strconv.FormatFloat(center.Y, 'f', 2, 64),
+			strconv.FormatFloat(r, 'f', 2, 64)
+		switch {
+		case useLines:
+			fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s" stroke-width="1"/>`+"\n", cx, cy, cr, svgColor(rgb))
+		case r < minShadedRadius:
+			fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", cx, cy, cr, svgColor(rgb))
+		default:
+			fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" fill="url(#fruit)"/>`+"\n", cx, cy, cr)
+		}
+	}
+}
+
+// writeSVGSnow is the SVG equivalent of drawSnow.
+func writeSVGSnow(bw *bufio.Writer, b, parent *Branch, cover float64, useLines bool) {
+	points := b.snowCap(parent, cover)
+	if points == nil {
+		return
+	}
+	if !useLines {
+		fmt.Fprintf(bw, `<path d="%s" fill="%s"/>`+"\n", svgPath(nil, points), svgColor(snowColor))
+		return
+	}
+	var buf []byte
+	for i := 0; i < len(points)/2; i += 2 {
+		buf = appendPoint(buf, Point{X: points[i], Y: points[i+1]}, i > 0)
+	}
+	fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1"/>`+"\n", buf, svgColor(snowColor))
+}
+
+// svgPath appends the closed path data for the (x, y) points.
+func svgPath(buf []byte, points []float64) []byte {
+	for i := 0; i < len(points); i += 2 {
+		if i == 0 {
+			buf = append(buf, 'M')
+		} else {
+			buf = append(buf, " L"...)
+		}
+		buf = appendPoint(buf, Point{X: points[i], Y: points[i+1]}, false)
+	}
+	return append(buf, " Z"...)
+}
+
+// appendPoint appends "x,y" with 2 decimals, preceded by a space if sep is true.
+func appendPoint(buf []byte, p Point, sep bool) []byte {
+	if sep {
+		buf = append(buf, ' ')
+	}
+	buf = strconv.AppendFloat(buf, p.X, 'f', 2, 64)
+	buf = append(buf, ',')
+	return strconv.AppendFloat(buf, p.Y, 'f', 2, 64)
+}
+
+func svgColor(c tcolor.RGBColor) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
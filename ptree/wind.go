@@ -0,0 +1,124 @@
+package ptree
+
+import (
+	"math"
+
+	"fortio.org/rand"
+)
+
+// Wind makes branches sway and leaves flutter over time (see [Canvas.Time]).
+// Gusts are random but fully determined by the Rand used to create the Wind.
+type Wind struct {
+	Strength float64
+	gusts    [3]gust
+}
+
+type gust struct {
+	freq, phase, amp float64
+}
+
+// NewWind creates a wind of the given strength (1 = moderate breeze) with random gusts.
+func NewWind(strength float64, rnd rand.Rand) *Wind {
+	w := &Wind{Strength: strength}
+	for i := range w.gusts {
+		w.gusts[i] = gust{
+			freq:  0.05 + 0.3*rnd.Float64(), // slow variations, in Hz
+			phase: 2 * math.Pi * rnd.Float64(),
+			amp:   0.2 + 0.3*rnd.Float64(),
+		}
+	}
+	return w
+}
+
+// Force returns the wind intensity at time t (in seconds), always >= 0: a base
+// breeze with gusts on top.
+func (w *Wind) Force(t float64) float64 {
+	f := 0.5
+	for _, g := range w.gusts {
+		f += g.amp * math.Sin(2*math.Pi*g.freq*t+g.phase)
+	}
+	return w.Strength * max(0, f)
+}
+
+// phase returns a stable pseudo random phase for the given index (so branches and leaves
+// don't all move in unison) without consuming the tree Rand.
+func phase(i int) float64 {
+	v := math.Sin(float64(i)*12.9898) * 43758.5453
+	return 2 * math.Pi * (v - math.Floor(v))
+}
+
+// flutter returns the extra rotation of leaf i at time t.
+func (w *Wind) flutter(t float64, i int) float64 {
+	return 0.4 * w.Force(t) * math.Sin(2*math.Pi*2.5*t+phase(i))
+}
+
+// sway returns the rotation of branch i (relative to its parent) at time t: the wind
+// pushes the branch (to the right, i.e clockwise) and makes it oscillate, more so for
+// deeper and thinner branches.
+func (w *Wind) sway(t float64, i int, depthFrac, thinness float64) float64 {
+	force := w.Force(t)
+	k := 0.03 * (0.3 + depthFrac) * (0.5 + thinness)
+	return -k * force * (0.6 + 0.4*math.Sin(2*math.Pi*0.8*t+phase(i)))
+}
+
+// rotate rotates point p around center by angle (in the [Branch.Angle] convention, counter
+// clockwise with Y up, so clockwise in image coordinates).
+func rotate(p, center Point, angle float64) Point {
+	sin, cos := math.Sincos(angle)
+	dx, dy := p.X-center.X, p.Y-center.Y
+	return Point{X: center.X + dx*cos + dy*sin, Y: center.Y - dx*sin + dy*cos}
+}
+
+// swayedBranches returns a copy of the branches moved by the wind at the current [Canvas.Time].
+// Each branch rotates around its start, and carries along all its descendants.
+func (c *Canvas) swayedBranches() []*Branch {
+	n := len(c.Branches)
+	if cap(c.swayBuf) < n {
+		c.swayBuf = make([]Branch, n)
+		c.swayed = make([]*Branch, n)
+	}
+	c.swayBuf = c.swayBuf[:n]
+	c.swayed = c.swayed[:n]
+	// Accumulated transform per branch: rotation by rot[i] around the original origin then
+	// translation so that the original start lands on the moved start.
+	rot := make([]float64, n)
+	var trunkWidth float64
+	if n > 0 {
+		trunkWidth = max(1, c.Branches[0].StartWidth)
+	}
+	maxDepth := max(1, c.MaxDepth)
+	for i, b := range c.Branches {
+		nb := &c.swayBuf[i]
+		*nb = *b
+		c.swayed[i] = nb
+		if b.Root {
+			continue // anchored in the ground
+		}
+		start := b.Start
+		parentRot := 0.0
+		if b.Parent >= 0 {
+			// Move the start the same way the parent moved: rotate around the parent
+			// original start then translate to its new start.
+			p := c.Branches[b.Parent]
+			np := c.swayed[b.Parent]
+			parentRot = rot[b.Parent]
+			start = rotate(b.Start, p.Start, parentRot)
+			start.X += np.Start.X - p.Start.X
+			start.Y += np.Start.Y - p.Start.Y
+		}
+		depthFrac := min(1, float64(b.Depth)/float64(maxDepth))
+		thinness := max(0, 1-b.EndWidth/trunkWidth)
+		rot[i] = parentRot + c.Wind.sway(c.Time, i, depthFrac, thinness)
+		// Rigid motion: rotate every point around the original start, then translate.
+		move := func(pt Point) Point {
+			r := rotate(pt, b.Start, rot[i])
+			return Point{X: r.X + start.X - b.Start.X, Y: r.Y + start.Y - b.Start.Y}
+		}
+		nb.Start = start
+		nb.End = move(b.End)
+		nb.Ctrl1 = move(b.Ctrl1)
+		nb.Ctrl2 = move(b.Ctrl2)
+		nb.Angle = b.Angle + rot[i]
+	}
+	return c.swayed
+}
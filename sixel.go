@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// Default terminal cell size in pixels when it can't be queried.
+const (
+	defaultCellPixelW = 10
+	defaultCellPixelH = 20
+)
+
+// CellPixelSize returns the size of a terminal cell in pixels (used to size sixel images).
+func CellPixelSize(cols, rows int) (w, h int) {
+	xpixel, ypixel := terminalPixelSize()
+	if xpixel <= 0 || ypixel <= 0 || cols <= 0 || rows <= 0 {
+		return defaultCellPixelW, defaultCellPixelH
+	}
+	return xpixel / cols, ypixel / rows
+}
+
+// palette is an adaptive palette of up to 256 colors for an image (sixel and GIF).
+type palette struct {
+	colors []color.RGBA // opaque colors
+	index  map[uint16]uint8
+}
+
+// rgb555 reduces a color to 15 bits, the resolution used to build and look up the palette.
+func rgb555(c color.RGBA) uint16 {
+	return uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+}
+
+// newPalette picks the (up to) maxColors most frequent colors (at 15 bits resolution) of the
+// visible pixels, other colors are mapped to the closest of those.
+func newPalette(img *image.RGBA, maxColors int) *palette {
+	counts := make(map[uint16]int)
+	sums := make(map[uint16][3]int)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ok := opaque(img.RGBAAt(x, y))
+			if !ok {
+				continue
+			}
+			k := rgb555(c)
+			counts[k]++
+			s := sums[k]
+			sums[k] = [3]int{s[0] + int(c.R), s[1] + int(c.G), s[2] + int(c.B)}
+		}
+	}
+	keys := make([]uint16, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	p := &palette{index: make(map[uint16]uint8, len(keys))}
+	for i, k := range keys {
+		if i == maxColors {
+			break
+		}
+		n, s := counts[k], sums[k]
+		p.colors = append(p.colors, color.RGBA{R: uint8(s[0] / n), G: uint8(s[1] / n), B: uint8(s[2] / n), A: 255})
+		p.index[k] = uint8(i)
+	}
+	// Map the remaining (less frequent) colors to the closest palette entry.
+	for _, k := range keys[len(p.colors):] {
+		n, s := counts[k], sums[k]
+		c := color.RGBA{R: uint8(s[0] / n), G: uint8(s[1] / n), B: uint8(s[2] / n)}
+		p.index[k] = p.closest(c)
+	}
+	return p
+}
+
+func (p *palette) closest(c color.RGBA) uint8 {
+	best, bestD := 0, 1<<30
+	for i, pc := range p.colors {
+		dr, dg, db := int(c.R)-int(pc.R), int(c.G)-int(pc.G), int(c.B)-int(pc.B)
+		if d := dr*dr + dg*dg + db*db; d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return uint8(best) //nolint:gosec // at most 256 colors
+}
+
+// opaque returns the un-premultiplied color of visible pixels (alpha of at least 50%),
+// sixels having no partial transparency.
+func opaque(c color.RGBA) (color.RGBA, bool) {
+	if c.A < 0x80 {
+		return color.RGBA{}, false
+	}
+	a := uint32(c.A)
+	unmultiply := func(v uint8) uint8 { return uint8(uint32(v) * 0xff / a) }
+	return color.RGBA{R: unmultiply(c.R), G: unmultiply(c.G), B: unmultiply(c.B), A: 255}, true
+}
+
+// SixelImage sends an image using the DEC sixel graphics protocol: the image is quantized to
+// an adaptive 256 colors palette and transparent pixels are left untouched (so the terminal
+// background shows through). The image is drawn at the cursor position, at its own pixel
+// size (see [CellPixelSize]).
+// https://vt100.net/docs/vt3xx-gp/chapter14.html
+func SixelImage(w io.Writer, img *image.RGBA) error {
+	bw := bufio.NewWriter(w)
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	p := newPalette(img, 256)
+	// DCS P1=0 (aspect ratio 2:1 default, overridden by the raster attributes), P2=1 transparent background.
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range p.colors {
+		// Colors are in percent.
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, int(c.R)*100/255, int(c.G)*100/255, int(c.B)*100/255)
+	}
+	// Indexes of each pixel of a band of 6 rows (-1 for transparent).
+	band := make([]int, 6*width)
+	var bits []byte
+	for y0 := 0; y0 < height; y0 += 6 {
+		used := make([]bool, len(p.colors))
+		for dy := range 6 {
+			for x := range width {
+				band[dy*width+x] = -1
+				if y0+dy >= height {
+					continue
+				}
+				if c, ok := opaque(img.RGBAAt(b.Min.X+x, b.Min.Y+y0+dy)); ok {
+					idx := p.index[rgb555(c)]
+					band[dy*width+x] = int(idx)
+					used[idx] = true
+				}
+			}
+		}
+		first := true
+		for ci, u := range used {
+			if !u {
+				continue
+			}
+			if !first {
+				bw.WriteByte('$') // back to the start of the band for the next color
+			}
+			first = false
+			bits = bits[:0]
+			for x := range width {
+				var v byte
+				for dy := range 6 {
+					if band[dy*width+x] == ci {
+						v |= 1 << dy
+					}
+				}
+				bits = append(bits, '?'+v)
+			}
+			fmt.Fprintf(bw, "#%d", ci)
+			writeSixelRLE(bw, bits)
+		}
+		bw.WriteByte('-') // next band
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixelRLE writes the sixel characters with run length encoding (!count char).
+func writeSixelRLE(w *bufio.Writer, data []byte) {
+	// Trailing empty sixels don't need to be sent.
+	end := len(data)
+	for end > 0 && data[end-1] == '?' {
+		end--
+	}
+	for i := 0; i < end; {
+		j := i + 1
+		for j < end && data[j] == data[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, data[i])
+		} else {
+			for range n {
+				w.WriteByte(data[i])
+			}
+		}
+		i = j
+	}
+}
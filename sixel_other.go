@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// terminalPixelSize isn't available on this platform, the default cell size is used.
+func terminalPixelSize() (xpixel, ypixel int) {
+	return 0, 0
+}
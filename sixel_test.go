@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var (
+	testRed   = color.RGBA{R: 255, A: 255}
+	testGreen = color.RGBA{G: 255, A: 255}
+	testBlue  = color.RGBA{B: 255, A: 255}
+)
+
+// testImage returns a 4x6 image (a single sixel band): 3 rows of red, 7 green pixels, 1
+// transparent pixel and a row of blue, so the palette is red, green, blue by frequency.
+func testImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 6))
+	for x := range 4 {
+		for y := range 3 {
+			img.SetRGBA(x, y, testRed)
+		}
+		img.SetRGBA(x, 3, testGreen)
+		if x < 3 {
+			img.SetRGBA(x, 4, testGreen)
+		}
+		img.SetRGBA(x, 5, testBlue)
+	}
+	return img
+}
+
+func TestSixelImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SixelImage(&buf, testImage()); err != nil {
+		t.Fatalf("SixelImage() unexpected error: %v", err)
+	}
+	want := "\x1bP0;1;0q\"1;1;4;6" + // DCS with transparent background and raster attributes
+		"#0;2;100;0;0#1;2;0;100;0#2;2;0;0;100" + // color registers in percent
+		"#0!4F$#1WWWG$#2!4_-" + // rows 0-2 red, 3-4 green (but the last pixel), 5 blue
+		"\x1b\\"
+	if got := buf.String(); got != want {
+		t.Errorf("SixelImage() = %q, want %q", got, want)
+	}
+}
+
+func TestPaletteClosest(t *testing.T) {
+	img := testImage()
+	img.SetRGBA(3, 3, color.RGBA{R: 250, G: 10, A: 255})
+	p := newPalette(img, 3)
+	if len(p.colors) != 3 {
+		t.Fatalf("got %d colors, want 3", len(p.colors))
+	}
+	// The least frequent color is mapped to the closest of the palette ones.
+	if got := p.index[rgb555(color.RGBA{R: 250, G: 10})]; got != 0 {
+		t.Errorf("near red mapped to %d, want 0 (red)", got)
+	}
+}
+
+func TestSixelRLE(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"???", ""},
+		{"AAA", "AAA"},
+		{"AAAA", "!4A"},
+		{"ABBBBBC??", "A!5BC"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		writeSixelRLE(w, []byte(tt.in))
+		_ = w.Flush()
+		if got := buf.String(); got != tt.want {
+			t.Errorf("writeSixelRLE(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+var sixelRegister = regexp.MustCompile(`#(\d+);2;(\d+);(\d+);(\d+)`)
+
+// TestPaletteSixelGIF checks each pixel maps to the same color through the sixel color
+// registers and the GIF palette (shifted by 1 for the transparent index).
+func TestPaletteSixelGIF(t *testing.T) {
+	img := testImage()
+	img.SetRGBA(3, 3, color.RGBA{R: 250, G: 10, A: 255})
+	p := newPalette(img, 256) // as in SixelImage
+	var buf bytes.Buffer
+	if err := SixelImage(&buf, img); err != nil {
+		t.Fatalf("SixelImage() unexpected error: %v", err)
+	}
+	registers := make(map[int]string)
+	for _, m := range sixelRegister.FindAllStringSubmatch(buf.String(), -1) {
+		i, _ := strconv.Atoi(m[1])
+		registers[i] = m[2] + ";" + m[3] + ";" + m[4]
+	}
+	pal := p.gifPalette()
+	if len(registers) != len(p.colors) || len(pal) != len(p.colors)+1 {
+		t.Fatalf("got %d sixel registers and %d GIF colors for a %d colors palette", len(registers), len(pal), len(p.colors))
+	}
+	frame := gifFrame(img, p, pal)
+	for y := range 6 {
+		for x := range 4 {
+			c, ok := opaque(img.RGBAAt(x, y))
+			gifIdx := int(frame.ColorIndexAt(x, y))
+			if !ok {
+				if gifIdx != 0 {
+					t.Errorf("transparent pixel %d,%d has GIF index %d, want 0", x, y, gifIdx)
+				}
+				continue
+			}
+			idx := int(p.index[rgb555(c)])
+			if gifIdx != idx+1 {
+				t.Errorf("pixel %d,%d GIF index %d, want sixel index %d + 1", x, y, gifIdx, idx)
+			}
+			r, g, b, _ := pal[gifIdx].RGBA()
+			gifColor := fmt.Sprintf("%d;%d;%d", (r>>8)*100/255, (g>>8)*100/255, (b>>8)*100/255)
+			if registers[idx] != gifColor {
+				t.Errorf("pixel %d,%d sixel color #%d %s, GIF color %s", x, y, idx, registers[idx], gifColor)
+			}
+		}
+	}
+}
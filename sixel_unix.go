@@ -0,0 +1,21 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalPixelSize returns the terminal size in pixels as reported by the TIOCGWINSZ ioctl
+// (0 if not available).
+func terminalPixelSize() (xpixel, ypixel int) {
+	for _, f := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ) //nolint:gosec // fd fits in an int
+		if err == nil && ws.Xpixel > 0 && ws.Ypixel > 0 {
+			return int(ws.Xpixel), int(ws.Ypixel)
+		}
+	}
+	return 0, 0
+}
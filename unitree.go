@@ -1,64 +1,167 @@
 package main
 
 import (
+	"image"
 	"strings"
 
-	"fortio.org/terminal/ansipixels"
+	"fortio.org/log"
+	"fortio.org/rand"
+	"fortio.org/tbonsai/ptree"
+	"fortio.org/terminal/ansipixels/tcolor"
 )
 
 const (
 	BlockFull = "█"
-	BlockBL   = "◢" // bottom left triangle
-	BlockBR   = "◣" // bottom right triangle
-	BlockTL   = "◥" // top left triangle
-	BlockTR   = "◤" // top right triangle
+	BlockBL   = "◢" // lower right half filled (for bottom left of shapes)
+	BlockBR   = "◣" // lower left half filled (for bottom right of shapes)
+	BlockTL   = "◥" // upper right half filled (for top left of shapes)
+	BlockTR   = "◤" // upper left half filled (for top right of shapes)
 )
 
-// Example of what we want to generate procedurally.
-const trunk = `
-██   ◢██◤
-◥█◣  ███
- ◥█◣◢██◤
- ◢████◤
- ◥████◣
- ◢█████◣
-`
+// Size in pixels of the image area each glyph cell covers (terminal cells are about twice as tall as wide).
+const (
+	GlyphCellW = 8
+	GlyphCellH = 16
+)
 
-func (st *State) TreeBase() {
-	w := st.ap.W
-	h := st.ap.H
-	cx := (w - 1) / 2
+// Glyph is one terminal cell of a glyph image.
+type Glyph struct {
+	Str   string // " " for an empty cell
+	Color tcolor.RGBColor
+}
 
-	// Generate the trunk as a string
-	// ... (or reuse ptree and convert to string like above)
+// Glyph masks indexes, the 4 triangles are the halves of the cell split by either diagonal.
+const (
+	triLowerRight = iota // below the anti diagonal
+	triLowerLeft         // below the diagonal
+	triUpperLeft         // above the anti diagonal
+	triUpperRight        // above the diagonal
+)
 
-	// Draw it line by line
-	l := LineByLine{x: cx - 4, y: h - 9}
-	l.DrawMulti(st.ap, trunk)
-}
+var triangleGlyphs = [4]string{BlockBL, BlockBR, BlockTR, BlockTL}
 
-type LineByLine struct {
-	x, y int
+// ImageToGlyphs converts an image into a grid of block and triangle glyphs, one per
+// GlyphCellW x GlyphCellH pixels, choosing for each cell the glyph whose shape best matches
+// the (alpha) coverage of the pixels and the average color of the covered ones.
+func ImageToGlyphs(img *image.RGBA) [][]Glyph {
+	b := img.Bounds()
+	cols, rows := b.Dx()/GlyphCellW, b.Dy()/GlyphCellH
+	grid := make([][]Glyph, rows)
+	for row := range rows {
+		grid[row] = make([]Glyph, cols)
+		for col := range cols {
+			grid[row][col] = cellGlyph(img, b.Min.X+col*GlyphCellW, b.Min.Y+row*GlyphCellH)
+		}
+	}
+	return grid
 }
 
-func (l *LineByLine) DrawMulti(ap *ansipixels.AnsiPixels, s string) {
-	for line := range strings.SplitSeq(s, "\n") {
-		if line == "" {
-			continue
+// cellGlyph picks the glyph minimizing the squared error between its mask and the coverage:
+// sum over the mask of (1-a)^2 plus outside of it a^2, which up to a constant is the mask
+// area minus twice the coverage inside of it.
+func cellGlyph(img *image.RGBA, x0, y0 int) Glyph {
+	var tri [4]float64 // coverage inside each triangle
+	var total, r, g, bl float64
+	for y := range GlyphCellH {
+		fy := (float64(y) + 0.5) / GlyphCellH
+		for x := range GlyphCellW {
+			off := img.PixOffset(x0+x, y0+y)
+			pix := img.Pix[off : off+4 : off+4]
+			if pix[3] == 0 {
+				continue
+			}
+			a := float64(pix[3]) / 255
+			// Premultiplied alpha so this is already weighted by coverage.
+			r += float64(pix[0])
+			g += float64(pix[1])
+			bl += float64(pix[2])
+			total += a
+			fx := (float64(x) + 0.5) / GlyphCellW
+			if fx+fy >= 1 {
+				tri[triLowerRight] += a
+			} else {
+				tri[triUpperLeft] += a
+			}
+			if fy >= fx {
+				tri[triLowerLeft] += a
+			} else {
+				tri[triUpperRight] += a
+			}
+		}
+	}
+	area := float64(GlyphCellW * GlyphCellH)
+	best, bestScore := " ", 0.0 // empty cell
+	if score := area - 2*total; score < bestScore {
+		best, bestScore = BlockFull, score
+	}
+	for i, c := range tri {
+		if score := area/2 - 2*c; score < bestScore {
+			best, bestScore = triangleGlyphs[i], score
 		}
-		l.DrawLine(ap, line)
 	}
+	if best == " " {
+		return Glyph{Str: best}
+	}
+	return Glyph{Str: best, Color: tcolor.RGBColor{R: uint8(r / total), G: uint8(g / total), B: uint8(bl / total)}}
 }
 
-func (l *LineByLine) DrawLine(ap *ansipixels.AnsiPixels, s string) {
-	leadingSpaces := 0
-	for _, c := range s {
-		if c != ' ' {
-			break
+// WriteGlyphs draws the glyph grid with its top left corner at x, y. Empty cells are skipped
+// (so whatever is already on screen there remains), glyphs are colored if colored is true
+// (otherwise in the default foreground color).
+func (st *State) WriteGlyphs(grid [][]Glyph, x, y int, colored bool) {
+	var sb strings.Builder
+	for row, line := range grid {
+		var last tcolor.RGBColor
+		for col := 0; col < len(line); col++ {
+			if line[col].Str == " " {
+				continue
+			}
+			// Run of non empty cells.
+			start := col
+			sb.Reset()
+			for ; col < len(line) && line[col].Str != " "; col++ {
+				if colored && (col == start || line[col].Color != last) {
+					last = line[col].Color
+					sb.WriteString(st.ap.ColorOutput.Foreground(last.Color()))
+				}
+				sb.WriteString(line[col].Str)
+			}
+			st.ap.WriteAtStr(x+start, y+row, sb.String())
 		}
-		leadingSpaces++
 	}
-	s = s[leadingSpaces:]
-	ap.WriteAtStr(l.x+leadingSpaces, l.y, s)
-	l.y++
+	if colored {
+		st.ap.WriteString(tcolor.Reset)
+	}
+}
+
+// Size in cells and generation parameters of the welcome screen tree base.
+const (
+	treeBaseW    = 10
+	treeBaseH    = 6
+	treeBaseSeed = 13
+)
+
+func (st *State) TreeBase() {
+	w := st.ap.W
+	h := st.ap.H
+	cx := (w - 1) / 2
+
+	// Generate the trunk and first branches with ptree and convert them to glyphs.
+	c := ptree.Canvas{
+		Width:          treeBaseW * GlyphCellW,
+		Height:         treeBaseH * GlyphCellH,
+		TrunkColor:     tcolor.RGBColor{R: 255, G: 255, B: 255},
+		MaxDepth:       1,
+		Rand:           rand.New(treeBaseSeed),
+		Spread:         1.3,
+		TrunkWidthPct:  40,
+		TrunkHeightPct: 60,
+	}
+	if err := c.Generate(); err != nil {
+		log.Errf("failed to generate the tree base: %v", err)
+		return
+	}
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	ptree.DrawTree(img, &c, false)
+	st.WriteGlyphs(ImageToGlyphs(img), cx-treeBaseW/2+1, h-3-treeBaseH, false)
 }